@@ -1,28 +1,372 @@
 package a2a
 
 import (
+	"context"
+	"fmt"
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/templates"
 	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
-func Remember() {
+// Reminder looks up today's and tomorrow's birthdays and sends
+// wishes and reminders through a Notifier
+type Reminder struct {
+	birthdayStore store.Repository
+	wishes        clients.WishGenerator
+	notifier      Notifier
+	ledger        *store.Ledger
+	history       *store.WishHistory
+	calendar      store.Calendar
+	todayJob      Schedule
+	now           func() time.Time
+}
+
+// Schedule tells when a scheduled job next runs after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Delivery describes one notification the reminder tried to send
+type Delivery struct {
+	BirthdayID string `json:"birthday_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Tenant     string `json:"tenant,omitempty"`
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	Source     string `json:"source,omitempty"`
+	Sent       bool   `json:"sent"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report summarizes a reminder run
+type Report struct {
+	Date     string     `json:"date"`
+	Today    []Delivery `json:"today,omitempty"`
+	Tomorrow []Delivery `json:"tomorrow,omitempty"`
+	Digest   []Delivery `json:"digest,omitempty"`
+	Belated  []Delivery `json:"belated,omitempty"`
+	Sent     int        `json:"sent"`
+	Skipped  int        `json:"skipped"`
+	Failed   int        `json:"failed"`
+}
+
+// NewReminder creates a reminder. The ledger may be nil, in which case
+// nothing stops the same reminder from being sent twice. The wish history
+// may be nil, in which case wishes aren't recorded or checked for repeats.
+func NewReminder(birthdayStore store.Repository, wishes clients.WishGenerator, notifier Notifier, ledger *store.Ledger, history *store.WishHistory) *Reminder {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &Reminder{
+		birthdayStore: birthdayStore,
+		wishes:        wishes,
+		notifier:      notifier,
+		ledger:        ledger,
+		history:       history,
+		now:           time.Now,
+	}
+}
+
+// SetCalendar sets the calendar used to decide when birthdays fall,
+// e.g. to change the leap-day policy
+func (r *Reminder) SetCalendar(calendar store.Calendar) {
+	r.calendar = calendar
+}
+
+// SetTodaySchedule tells CatchUp when the daily wish job runs. Without one,
+// CatchUp leaves today's wishes to that job.
+func (r *Reminder) SetTodaySchedule(schedule Schedule) {
+	r.todayJob = schedule
+}
+
+// SetClock replaces the time source used to decide what "today" is
+func (r *Reminder) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Remember runs the daily birthday check and reports what was sent
+func (r *Reminder) Remember(ctx context.Context) *Report {
 	log.Println("Starting daily birthday check...")
 
-	checkTomorrowBirthdays()
+	now := r.now()
+	report := &Report{Date: now.Format("2006-01-02")}
 
-	checkTodayBirthdays()
+	report.Tomorrow = r.checkTomorrowBirthdays(ctx, now)
+	report.Today = r.checkTodayBirthdays(ctx, now)
+
+	return report.tally()
+}
+
+// Today sends wishes for today's birthdays
+func (r *Reminder) Today(ctx context.Context) *Report {
+	now := r.now()
+	report := &Report{Date: now.Format("2006-01-02")}
+	report.Today = r.checkTodayBirthdays(ctx, now)
+	return report.tally()
+}
+
+// Tomorrow sends heads-up reminders for tomorrow's birthdays
+func (r *Reminder) Tomorrow(ctx context.Context) *Report {
+	now := r.now()
+	report := &Report{Date: now.Format("2006-01-02")}
+	report.Tomorrow = r.checkTomorrowBirthdays(ctx, now)
+	return report.tally()
 }
-func checkTomorrowBirthdays() {
-	tomorrow := time.Now().AddDate(0, 0, 1)
+
+// WeeklyDigest sends each birthday book a summary of its birthdays in the
+// next seven days
+func (r *Reminder) WeeklyDigest(ctx context.Context) *Report {
+	now := r.now()
+	report := &Report{Date: now.Format("2006-01-02")}
+
+	log.Printf("📅 Building weekly birthday digest from %s", report.Date)
+
+	type upcoming struct {
+		birthday store.Birthday
+		date     time.Time
+	}
+
+	var week []upcoming
+	for i := 0; i < 7; i++ {
+		day := now.AddDate(0, 0, i)
+		for _, b := range r.birthdaysOn(day) {
+			week = append(week, upcoming{birthday: b, date: day})
+		}
+	}
+
+	if len(week) == 0 {
+		log.Println("No birthdays this week, skipping digest")
+		return report.tally()
+	}
+
+	sort.Slice(week, func(i, j int) bool { return week[i].date.Before(week[j].date) })
+
+	// One digest per tenant so no book sees another's birthdays
+	byTenant := make(map[string][]upcoming)
+	var tenants []string
+	for _, u := range week {
+		if _, ok := byTenant[u.birthday.Tenant]; !ok {
+			tenants = append(tenants, u.birthday.Tenant)
+		}
+		byTenant[u.birthday.Tenant] = append(byTenant[u.birthday.Tenant], u)
+	}
+
+	for _, tenant := range tenants {
+		var sb strings.Builder
+		sb.WriteString("📅 Birthdays this week:\n\n")
+		birthdays := make([]store.Birthday, 0, len(byTenant[tenant]))
+		for _, u := range byTenant[tenant] {
+			fmt.Fprintf(&sb, "• %s - %s, %s %d\n", u.birthday.Name, u.date.Weekday(), u.date.Month(), u.date.Day())
+			birthdays = append(birthdays, u.birthday)
+		}
+
+		d := Delivery{Kind: KindDigest, Tenant: tenant, Message: sb.String()}
+		n := Notification{Kind: KindDigest, Tenant: tenant, Birthdays: birthdays, Text: d.Message}
+		if err := r.notifier.Notify(ctx, n); err != nil {
+			log.Printf("Failed to send weekly digest: %v", err)
+			d.Error = err.Error()
+		} else {
+			d.Sent = true
+		}
+		report.Digest = append(report.Digest, d)
+	}
+
+	return report.tally()
+}
+
+// CatchUp sends belated wishes for birthdays in the last `days` days that
+// were never wished, e.g. because the server was down on the day. Once the
+// daily wish job's time has passed it also sends today's wishes, in case
+// the process was down when the job fired; the ledger keeps them from going
+// out twice. Before that time they are left to the job.
+func (r *Reminder) CatchUp(ctx context.Context, days int) *Report {
+	now := r.now()
+	report := &Report{Date: now.Format("2006-01-02")}
+	report.Belated = []Delivery{}
+
+	if r.ledger == nil || days <= 0 {
+		return report.tally()
+	}
+
+	log.Printf("🕰️ Catching up on birthdays missed in the last %d days", days)
+
+	for i := days; i >= 1; i-- {
+		day := now.AddDate(0, 0, -i)
+		for _, b := range r.birthdaysOn(day) {
+			if r.ledger.Sent(b.ID, day.Year(), KindWish) || r.ledger.Sent(b.ID, day.Year(), KindBelated) {
+				continue
+			}
+			wish := r.belatedWish(b, i)
+			d := r.deliver(ctx, b, day.Year(), KindBelated, wish.Text, wish.Source)
+			if d.Sent {
+				r.recordWish(b, wish)
+			}
+			report.Belated = append(report.Belated, d)
+		}
+	}
+	if r.todayJobRan(now) {
+		report.Today = r.checkTodayBirthdays(ctx, now)
+	}
+
+	return report.tally()
+}
+
+// todayJobRan reports whether the daily wish job was due to run today at
+// or before now
+func (r *Reminder) todayJobRan(now time.Time) bool {
+	if r.todayJob == nil {
+		return false
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := r.todayJob.Next(midnight.Add(-time.Minute))
+	return !next.IsZero() && !next.After(now)
+}
+
+func (report *Report) tally() *Report {
+	report.Sent, report.Skipped, report.Failed = 0, 0, 0
+	for _, group := range [][]Delivery{report.Today, report.Tomorrow, report.Digest, report.Belated} {
+		for _, d := range group {
+			switch {
+			case d.Sent:
+				report.Sent++
+			case d.Skipped:
+				report.Skipped++
+			default:
+				report.Failed++
+			}
+		}
+	}
+
+	log.Printf("Birthday check for %s finished: %d sent, %d skipped, %d failed",
+		report.Date, report.Sent, report.Skipped, report.Failed)
+	return report
+}
+
+// checkTomorrowBirthdays sends a heads-up for birthdays happening tomorrow
+func (r *Reminder) checkTomorrowBirthdays(ctx context.Context, now time.Time) []Delivery {
+	tomorrow := now.AddDate(0, 0, 1)
 
 	log.Printf("🔔 Checking for reminders - tomorrow is %s %d",
 		tomorrow.Month().String(), tomorrow.Day())
+
+	deliveries := []Delivery{}
+	for _, b := range r.birthdaysOn(tomorrow) {
+		text := fmt.Sprintf("🔔 Heads up! Tomorrow (%s %d) is %s's birthday. Don't forget to send your wishes! 🎂",
+			tomorrow.Month().String(), tomorrow.Day(), b.Name)
+		deliveries = append(deliveries, r.deliver(ctx, b, tomorrow.Year(), KindReminder, text, ""))
+	}
+	return deliveries
 }
 
 // checkTodayBirthdays sends birthday wishes for birthdays happening today
-func checkTodayBirthdays() {
-	today := time.Now()
-
+func (r *Reminder) checkTodayBirthdays(ctx context.Context, now time.Time) []Delivery {
 	log.Printf("🎂 Checking for birthdays - today is %s %d",
-		today.Month().String(), today.Day())
+		now.Month().String(), now.Day())
+
+	deliveries := []Delivery{}
+	for _, b := range r.birthdaysOn(now) {
+		if r.ledger != nil && r.ledger.Sent(b.ID, now.Year(), KindWish) {
+			deliveries = append(deliveries, Delivery{BirthdayID: b.ID, Name: b.Name, Tenant: b.Tenant, Kind: KindWish, Skipped: true})
+			continue
+		}
+		wish := r.generateWish(ctx, b)
+		d := r.deliver(ctx, b, now.Year(), KindWish, wish.Text, wish.Source)
+		if d.Sent {
+			r.recordWish(b, wish)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// birthdaysOn returns the birthdays in every tenant's book falling on the given day
+func (r *Reminder) birthdaysOn(day time.Time) []store.Birthday {
+	matches, err := store.BirthdaysOn(r.birthdayStore, r.calendar, store.AllTenants, day)
+	if err != nil {
+		log.Printf("Error loading birthdays for %s %d: %v", day.Month(), day.Day(), err)
+		return nil
+	}
+	return matches
+}
+
+// generateWish asks the wish providers for a wish, falling back to a fixed
+// message. It avoids the wishes the person got recently.
+func (r *Reminder) generateWish(ctx context.Context, b store.Birthday) clients.Wish {
+	age, _ := r.calendar.TurningOn(b, r.now())
+	req := clients.WishRequest{Name: b.Name, Age: age, Interests: b.Interests, Notes: b.Notes}
+	if r.history != nil {
+		req.Avoid, req.AvoidTemplates = r.history.Recent(b.ID)
+	}
+	return clients.WishOrFallback(ctx, r.wishes, req)
+}
+
+// belatedWish builds the message for a birthday that was missed `daysAgo` days ago
+func (r *Reminder) belatedWish(b store.Birthday, daysAgo int) clients.Wish {
+	q := templates.Query{Kind: templates.KindBelated}
+	if r.history != nil {
+		_, q.Exclude = r.history.Recent(b.ID)
+	}
+	text, name := templates.Default().RenderTemplate(q, templates.Data{Name: b.Name, DaysAgo: daysAgo})
+	return clients.Wish{Text: text, Source: "fallback", Template: name}
+}
+
+// recordWish adds a wish that was sent to the person's history
+func (r *Reminder) recordWish(b store.Birthday, wish clients.Wish) {
+	if r.history == nil {
+		return
+	}
+	err := r.history.Add(store.WishRecord{
+		BirthdayID: b.ID,
+		Text:       wish.Text,
+		Source:     wish.Source,
+		Model:      wish.Model,
+		Template:   wish.Template,
+		CreatedAt:  r.now(),
+	})
+	if err != nil {
+		log.Printf("Warning: sent a wish for %s but failed to record it: %v", b.Name, err)
+	}
+}
+
+// deliver sends a notification for the birthday in the given year, using the
+// ledger (when configured) to make sure it goes out at most once
+func (r *Reminder) deliver(ctx context.Context, b store.Birthday, year int, kind, text, source string) Delivery {
+	d := Delivery{
+		BirthdayID: b.ID,
+		Name:       b.Name,
+		Tenant:     b.Tenant,
+		Kind:       kind,
+		Message:    text,
+		Source:     source,
+	}
+
+	if r.ledger != nil && !r.ledger.Claim(b.ID, year, kind) {
+		log.Printf("Skipping %s for %s: already sent for %d", kind, b.Name, year)
+		d.Skipped = true
+		return d
+	}
+
+	err := r.notifier.Notify(ctx, Notification{Kind: kind, Tenant: b.Tenant, Birthday: &b, Text: text})
+	if err != nil {
+		log.Printf("Failed to send %s for %s: %v", kind, b.Name, err)
+		if r.ledger != nil {
+			r.ledger.Release(b.ID, year, kind)
+		}
+		d.Error = err.Error()
+		return d
+	}
+
+	if r.ledger != nil {
+		if err := r.ledger.Confirm(b.ID, year, kind, r.now()); err != nil {
+			log.Printf("Warning: sent %s for %s but failed to record it: %v", kind, b.Name, err)
+		}
+	}
+
+	d.Sent = true
+	return d
 }
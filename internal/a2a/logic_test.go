@@ -0,0 +1,182 @@
+package a2a
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"hazel_ai/internal/scheduler"
+	"hazel_ai/internal/store"
+)
+
+// recordingNotifier remembers every notification it was asked to send
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+	err  error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordingNotifier) kinds() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]string, 0, len(r.sent))
+	for _, n := range r.sent {
+		kinds = append(kinds, n.Kind)
+	}
+	return kinds
+}
+
+func newTestReminder(t *testing.T, now time.Time, birthdays ...store.NewBirthday) (*Reminder, *recordingNotifier) {
+	t.Helper()
+	dir := t.TempDir()
+
+	repo, err := store.NewBirthdayStore(filepath.Join(dir, "birthdays.json"), store.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range birthdays {
+		if _, err := repo.AddBirthday(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	ledger, err := store.NewLedger(filepath.Join(dir, "ledger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReminder(repo, nil, notifier, ledger, nil)
+	r.SetClock(func() time.Time { return now })
+	return r, notifier
+}
+
+func TestCatchUpSendsTodaysWishOnce(t *testing.T) {
+	now := time.Date(2025, 6, 15, 14, 0, 0, 0, time.UTC)
+	r, notifier := newTestReminder(t, now,
+		store.NewBirthday{Name: "Ada", Date: "06-15"},
+		store.NewBirthday{Name: "Grace", Date: "06-13"},
+	)
+	r.SetTodaySchedule(mustParseCron(t, "0 8 * * *"))
+
+	report := r.CatchUp(context.Background(), 3)
+	if len(report.Today) != 1 || !report.Today[0].Sent || report.Today[0].Name != "Ada" {
+		t.Fatalf("catch-up today = %+v, want a wish sent to Ada", report.Today)
+	}
+	if len(report.Belated) != 1 || !report.Belated[0].Sent || report.Belated[0].Name != "Grace" {
+		t.Fatalf("catch-up belated = %+v, want a belated wish sent to Grace", report.Belated)
+	}
+
+	// The daily job and a second restart must not send anything again
+	if report := r.Today(context.Background()); report.Sent != 0 || report.Skipped != 1 {
+		t.Errorf("daily job after catch-up sent %d, skipped %d; want 0 sent, 1 skipped", report.Sent, report.Skipped)
+	}
+	if report := r.CatchUp(context.Background(), 3); report.Sent != 0 {
+		t.Errorf("second catch-up sent %d notifications, want 0", report.Sent)
+	}
+
+	got := notifier.kinds()
+	if len(got) != 2 || got[0] != KindBelated || got[1] != KindWish {
+		t.Errorf("notifications = %v, want [%s %s]", got, KindBelated, KindWish)
+	}
+}
+
+func TestCatchUpLeavesTodayToTheDailyJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		schedule string
+	}{
+		{"before the daily job", time.Date(2025, 6, 15, 3, 0, 0, 0, time.UTC), "0 8 * * *"},
+		{"job not due today", time.Date(2025, 6, 15, 14, 0, 0, 0, time.UTC), "0 8 * * 1"},
+		{"no daily job", time.Date(2025, 6, 15, 14, 0, 0, 0, time.UTC), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, notifier := newTestReminder(t, tt.now,
+				store.NewBirthday{Name: "Ada", Date: "06-15"},
+				store.NewBirthday{Name: "Grace", Date: "06-13"},
+			)
+			if tt.schedule != "" {
+				r.SetTodaySchedule(mustParseCron(t, tt.schedule))
+			}
+
+			report := r.CatchUp(context.Background(), 3)
+			if len(report.Today) != 0 {
+				t.Errorf("catch-up today = %+v, want nothing", report.Today)
+			}
+			if len(report.Belated) != 1 || !report.Belated[0].Sent || report.Belated[0].Name != "Grace" {
+				t.Errorf("catch-up belated = %+v, want a belated wish sent to Grace", report.Belated)
+			}
+
+			// The daily job still sends today's wish when it fires
+			if report := r.Today(context.Background()); report.Sent != 1 {
+				t.Errorf("daily job sent %d, want 1", report.Sent)
+			}
+			got := notifier.kinds()
+			if len(got) != 2 || got[0] != KindBelated || got[1] != KindWish {
+				t.Errorf("notifications = %v, want [%s %s]", got, KindBelated, KindWish)
+			}
+		})
+	}
+}
+
+func mustParseCron(t *testing.T, spec string) *scheduler.Schedule {
+	t.Helper()
+	schedule, err := scheduler.ParseCron(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schedule
+}
+
+func TestCatchUpSkipsTodayAlreadyWished(t *testing.T) {
+	now := time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC)
+	r, notifier := newTestReminder(t, now, store.NewBirthday{Name: "Ada", Date: "06-15"})
+
+	if report := r.Today(context.Background()); report.Sent != 1 {
+		t.Fatalf("daily job sent %d, want 1", report.Sent)
+	}
+	if report := r.CatchUp(context.Background(), 3); report.Sent != 0 {
+		t.Errorf("catch-up after the daily job sent %d, want 0", report.Sent)
+	}
+	if n := len(notifier.kinds()); n != 1 {
+		t.Errorf("got %d notifications, want 1", n)
+	}
+}
+
+func TestRemindAcrossYearEnd(t *testing.T) {
+	now := time.Date(2025, 12, 31, 8, 0, 0, 0, time.UTC)
+	r, notifier := newTestReminder(t, now,
+		store.NewBirthday{Name: "Dec", Date: "12-31"},
+		store.NewBirthday{Name: "Jan", Date: "1990-01-01"},
+	)
+
+	report := r.Remember(context.Background())
+	if len(report.Today) != 1 || report.Today[0].Name != "Dec" || !report.Today[0].Sent {
+		t.Errorf("today = %+v, want a wish for Dec", report.Today)
+	}
+	if len(report.Tomorrow) != 1 || report.Tomorrow[0].Name != "Jan" || !report.Tomorrow[0].Sent {
+		t.Errorf("tomorrow = %+v, want a reminder for Jan", report.Tomorrow)
+	}
+
+	// Jan's reminder is recorded against the year the birthday falls in
+	r.SetClock(func() time.Time { return now.AddDate(0, 0, 1) })
+	if report := r.Today(context.Background()); report.Sent != 1 {
+		t.Errorf("Jan 1 sent %d wishes, want 1", report.Sent)
+	}
+	if n := len(notifier.kinds()); n != 3 {
+		t.Errorf("got %d notifications, want 3", n)
+	}
+}
@@ -0,0 +1,114 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/store"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification kinds sent by the reminder job
+const (
+	KindWish     = "wish"
+	KindReminder = "reminder"
+	KindDigest   = "digest"
+	KindBelated  = "belated"
+)
+
+// Notification is a single outbound message about someone's birthday,
+// or about several birthdays in the case of a digest
+type Notification struct {
+	Kind string `json:"kind"`
+	// Tenant is the birthday book the notification is for, so it can be
+	// routed to the right channel
+	Tenant    string           `json:"tenant,omitempty"`
+	Birthday  *store.Birthday  `json:"birthday,omitempty"`
+	Birthdays []store.Birthday `json:"birthdays,omitempty"`
+	Text      string           `json:"text"`
+}
+
+// Notifier delivers birthday notifications to wherever the chat lives
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the server log. It is used when no
+// outbound channel has been configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("📣 [%s] (%s) %s", n.Kind, n.Tenant, n.Text)
+	return nil
+}
+
+// Notifiers sends each notification through every notifier. It only fails
+// when none of them delivered it, so a channel that is down doesn't make the
+// others get the notification again on the next run. A LogNotifier in the
+// chain doesn't count as delivering anything.
+type Notifiers []Notifier
+
+func (ns Notifiers) Notify(ctx context.Context, n Notification) error {
+	delivered := false
+	var errs []error
+	for _, notifier := range ns {
+		err := notifier.Notify(ctx, n)
+		_, logOnly := notifier.(LogNotifier)
+		switch {
+		case err == nil && logOnly:
+		case err == nil:
+			delivered = true
+		case errors.Is(err, ErrNoSubscribers):
+		default:
+			errs = append(errs, err)
+		}
+	}
+	if delivered || len(errs) == 0 {
+		for _, err := range errs {
+			log.Printf("Failed to send %s notification through one channel: %v", n.Kind, err)
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookNotifier POSTs notifications as JSON to a fixed URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
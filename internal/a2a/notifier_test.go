@@ -0,0 +1,58 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hazel_ai/internal/store"
+)
+
+func TestNotifiers(t *testing.T) {
+	failing := &recordingNotifier{err: errors.New("channel down")}
+	noSubscribers := &recordingNotifier{err: ErrNoSubscribers}
+
+	tests := []struct {
+		name    string
+		chain   Notifiers
+		wantErr bool
+	}{
+		{"log only", Notifiers{LogNotifier{}}, false},
+		{"log and a working channel", Notifiers{LogNotifier{}, &recordingNotifier{}}, false},
+		{"log and a failing channel", Notifiers{LogNotifier{}, failing}, true},
+		{"a failing and a working channel", Notifiers{failing, &recordingNotifier{}}, false},
+		{"every channel failing", Notifiers{failing, failing}, true},
+		{"log and no subscribers", Notifiers{LogNotifier{}, noSubscribers}, false},
+		{"no subscribers and a failing channel", Notifiers{noSubscribers, failing}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.chain.Notify(context.Background(), Notification{Kind: KindWish, Text: "Happy birthday!"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFailedPushIsRetriedNextRun(t *testing.T) {
+	now := time.Date(2025, 6, 15, 8, 0, 0, 0, time.UTC)
+	r, _ := newTestReminder(t, now, store.NewBirthday{Name: "Ada", Date: "06-15"})
+
+	push := &recordingNotifier{err: errors.New("push failed after every retry")}
+	r.notifier = Notifiers{LogNotifier{}, push}
+
+	if report := r.Today(context.Background()); report.Failed != 1 || report.Sent != 0 {
+		t.Fatalf("first run sent %d, failed %d; want the wish reported as failed", report.Sent, report.Failed)
+	}
+
+	push.err = nil
+	if report := r.Today(context.Background()); report.Sent != 1 {
+		t.Fatalf("second run sent %d; want the wish sent once the push works", report.Sent)
+	}
+	if got := push.kinds(); len(got) != 1 {
+		t.Errorf("push delivered %d notifications, want 1", len(got))
+	}
+}
@@ -0,0 +1,57 @@
+package protocol
+
+// AgentCard describes the agent to A2A clients at /.well-known/agent.json
+type AgentCard struct {
+	Name                              string       `json:"name"`
+	Description                       string       `json:"description"`
+	URL                               string       `json:"url"`
+	Version                           string       `json:"version"`
+	Provider                          *Provider    `json:"provider,omitempty"`
+	DocumentationURL                  string       `json:"documentationUrl,omitempty"`
+	Capabilities                      Capabilities `json:"capabilities"`
+	DefaultInputModes                 []string     `json:"defaultInputModes"`
+	DefaultOutputModes                []string     `json:"defaultOutputModes"`
+	Skills                            []Skill      `json:"skills"`
+	SupportsAuthenticatedExtendedCard bool         `json:"supportsAuthenticatedExtendedCard"`
+}
+
+// Provider is the organization running the agent
+type Provider struct {
+	Organization string `json:"organization"`
+	URL          string `json:"url"`
+}
+
+// Capabilities are the optional A2A features the agent supports
+type Capabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// Skill is something the agent can do
+type Skill struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputModes  []string       `json:"inputModes"`
+	OutputModes []string       `json:"outputModes"`
+	Examples    []SkillExample `json:"examples"`
+}
+
+// SkillExample is a sample exchange with a skill. Telex shows these in its
+// agent directory.
+type SkillExample struct {
+	Input  ExampleMessage  `json:"input"`
+	Output *ExampleMessage `json:"output,omitempty"`
+}
+
+// ExampleMessage is the message of a skill example
+type ExampleMessage struct {
+	Parts []ExamplePart `json:"parts"`
+}
+
+// ExamplePart is a part of an example message
+type ExamplePart struct {
+	Text        string `json:"text"`
+	ContentType string `json:"contentType,omitempty"`
+}
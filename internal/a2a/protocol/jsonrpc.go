@@ -0,0 +1,247 @@
+// Package protocol holds the A2A wire format: JSON-RPC requests and
+// responses, messages and their parts, tasks, streaming events, push
+// notification configs and the agent card. Requests are decoded strictly so
+// malformed ones get the right JSON-RPC error instead of being half read.
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version is the JSON-RPC version every request and response carries
+const Version = "2.0"
+
+// Methods Hazel answers
+const (
+	MethodMessageSend      = "message/send"
+	MethodMessageStream    = "message/stream"
+	MethodTasksGet         = "tasks/get"
+	MethodTasksCancel      = "tasks/cancel"
+	MethodPushConfigSet    = "tasks/pushNotificationConfig/set"
+	MethodPushConfigGet    = "tasks/pushNotificationConfig/get"
+	MethodPushConfigList   = "tasks/pushNotificationConfig/list"
+	MethodPushConfigDelete = "tasks/pushNotificationConfig/delete"
+)
+
+// JSON-RPC and A2A error codes
+const (
+	CodeParseError        = -32700
+	CodeInvalidRequest    = -32600
+	CodeMethodNotFound    = -32601
+	CodeInvalidParams     = -32602
+	CodeInternalError     = -32603
+	CodeTaskNotFound      = -32001
+	CodeTaskNotCancelable = -32002
+	CodePushNotSupported  = -32003
+)
+
+// Request is a JSON-RPC request. ID is kept as sent so the response echoes
+// it exactly; it is nil for notifications.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response carrying either a result or an error
+type Response struct {
+	JSONRPC string
+	ID      json.RawMessage
+	Result  any
+	Error   *Error
+}
+
+// NewResponse creates a successful response to the request with the given ID
+func NewResponse(id json.RawMessage, result any) Response {
+	return Response{JSONRPC: Version, ID: id, Result: result}
+}
+
+// NewErrorResponse creates an error response to the request with the given ID
+func NewErrorResponse(id json.RawMessage, err *Error) Response {
+	return Response{JSONRPC: Version, ID: id, Error: err}
+}
+
+// MarshalJSON writes "result" or "error", never both, and "id" as null when
+// the request's ID couldn't be read
+func (r Response) MarshalJSON() ([]byte, error) {
+	id := r.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *Error          `json:"error"`
+		}{r.JSONRPC, id, r.Error})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  any             `json:"result"`
+	}{r.JSONRPC, id, r.Result})
+}
+
+// Error is a JSON-RPC error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// ParseError reports a body that isn't valid JSON
+func ParseError(detail string) *Error {
+	return &Error{Code: CodeParseError, Message: "Parse error - " + detail}
+}
+
+// InvalidRequest reports JSON that isn't a valid JSON-RPC request
+func InvalidRequest(detail string) *Error {
+	return &Error{Code: CodeInvalidRequest, Message: "Invalid Request - " + detail}
+}
+
+// MethodNotFound reports a method Hazel doesn't answer
+func MethodNotFound(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: "Method not found: " + method}
+}
+
+// InvalidParams reports params that are missing, of the wrong type or
+// out of range
+func InvalidParams(detail string) *Error {
+	return &Error{Code: CodeInvalidParams, Message: "Invalid params - " + detail}
+}
+
+// InternalError reports a failure on Hazel's side
+func InternalError(detail string) *Error {
+	return &Error{Code: CodeInternalError, Message: "Internal error: " + detail}
+}
+
+// TaskNotFound reports an unknown or expired task
+func TaskNotFound(id string) *Error {
+	return &Error{Code: CodeTaskNotFound, Message: "Task not found: " + id}
+}
+
+// TaskNotCancelable reports a task that has already finished
+func TaskNotCancelable(id string) *Error {
+	return &Error{Code: CodeTaskNotCancelable, Message: "Task cannot be canceled: " + id}
+}
+
+// PushNotSupported reports push notification requests while they are off
+func PushNotSupported() *Error {
+	return &Error{Code: CodePushNotSupported, Message: "Push Notification is not supported"}
+}
+
+// DecodeRequest reads a JSON-RPC request. The body must be a single JSON
+// object with only the JSON-RPC fields, "jsonrpc" set to "2.0", a method and
+// an ID that is a string, number or null. On error the returned request
+// holds whatever ID could be read, for the error response.
+func DecodeRequest(body []byte) (Request, *Error) {
+	var req Request
+	if !json.Valid(body) {
+		return req, ParseError("the body isn't valid JSON")
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return req, InvalidRequest("the body must be a JSON object")
+	}
+
+	// Pick up the ID first so even a broken request gets it back
+	var envelope struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && validID(envelope.ID) {
+		req.ID = envelope.ID
+	}
+
+	if err := decodeStrict(body, &req); err != nil {
+		return req, InvalidRequest(err.Error())
+	}
+	switch {
+	case req.JSONRPC != Version:
+		return req, InvalidRequest(`"jsonrpc" must be "2.0"`)
+	case req.Method == "":
+		return req, InvalidRequest("missing method")
+	case !validID(req.ID):
+		req.ID = nil
+		return req, InvalidRequest(`"id" must be a string, number or null`)
+	}
+	return req, nil
+}
+
+// DecodeParams reads the request's params into v and checks them when v has
+// a Validate method. Unknown fields are ignored so clients sending newer
+// A2A fields, or Telex's own, keep working, but known fields must have the
+// right type.
+func (r Request) DecodeParams(v any) *Error {
+	if len(r.Params) == 0 || string(r.Params) == "null" {
+		return InvalidParams("params are required")
+	}
+	if err := json.Unmarshal(r.Params, v); err != nil {
+		return InvalidParams(describe(err))
+	}
+	if validator, ok := v.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return InvalidParams(err.Error())
+		}
+	}
+	return nil
+}
+
+// decodeStrict decodes a JSON value, rejecting unknown fields
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.New(describe(err))
+	}
+	return nil
+}
+
+// describe turns a JSON decoding error into a message naming the field
+func describe(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Sprintf("%s must be %s, not %s", typeErr.Field, typeName(typeErr.Type.Kind().String()), typeErr.Value)
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "unknown field " + field
+	}
+	return strings.TrimPrefix(err.Error(), "json: ")
+}
+
+// typeName describes a Go kind the way JSON calls it
+func typeName(kind string) string {
+	switch kind {
+	case "slice", "array":
+		return "an array"
+	case "map", "struct", "ptr":
+		return "an object"
+	case "bool":
+		return "a boolean"
+	case "string":
+		return "a string"
+	default:
+		return "a number"
+	}
+}
+
+// validID reports whether a raw JSON-RPC ID is absent, a string, a number
+// or null
+func validID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package protocol
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// paramsFor returns the params type each method is decoded into
+func paramsFor(t *testing.T, method string) any {
+	t.Helper()
+	switch method {
+	case MethodMessageSend, MethodMessageStream:
+		return &MessageSendParams{}
+	case MethodTasksGet:
+		return &TaskQueryParams{}
+	case MethodTasksCancel:
+		return &TaskIDParams{}
+	case MethodPushConfigSet:
+		return &TaskPushNotificationConfig{}
+	case MethodPushConfigGet, MethodPushConfigList, MethodPushConfigDelete:
+		return &PushNotificationConfigParams{}
+	}
+	t.Fatalf("no params type for %s", method)
+	return nil
+}
+
+// assertSameJSON compares two JSON documents ignoring layout and key order
+func assertSameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("re-encoded JSON is invalid: %v\n%s", err, got)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("original JSON is invalid: %v", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("round trip changed the payload\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestRequestsRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = slices.DeleteFunc(files, func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), "response_")
+	})
+	if len(files) == 0 {
+		t.Fatal("no request payloads found")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			body, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, rpcErr := DecodeRequest(body)
+			if rpcErr != nil {
+				t.Fatalf("DecodeRequest: %v", rpcErr)
+			}
+			params := paramsFor(t, req.Method)
+			if rpcErr := req.DecodeParams(params); rpcErr != nil {
+				t.Fatalf("DecodeParams: %v", rpcErr)
+			}
+
+			encodedParams, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Params = encodedParams
+			encoded, err := json.Marshal(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameJSON(t, encoded, body)
+		})
+	}
+}
+
+func TestTelexMessageSend(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "telex_message_send.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, rpcErr := DecodeRequest(body)
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var params MessageSendParams
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+
+	if text := params.Message.Text(); text != "remember Alice's birthday is March 3rd" {
+		t.Errorf("Text() = %q, want only the text part", text)
+	}
+	// Telex sends the conversation so far as an array in a data part
+	history, ok := params.Message.Parts[1].Data.([]any)
+	if !ok || len(history) != 3 {
+		t.Errorf("history part = %#v, want an array of 3 messages", params.Message.Parts[1].Data)
+	}
+	push := params.Configuration.PushNotificationConfig
+	if push == nil || push.Authentication == nil || !slices.Equal(push.Authentication.Schemes, []string{"TelexApiKey"}) {
+		t.Errorf("push config = %+v, want the Telex webhook", push)
+	}
+}
+
+func TestMessageSendParts(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "request_message_send_parts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, rpcErr := DecodeRequest(body)
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var params MessageSendParams
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+
+	parts := params.Message.Parts
+	if len(parts) != 3 || parts[0].Kind != PartText || parts[1].Kind != PartData || parts[2].Kind != PartFile {
+		t.Fatalf("parts = %+v, want text, data and file", parts)
+	}
+	if data, _ := parts[1].Data.(map[string]any); data["name"] != "Bob" {
+		t.Errorf("data part = %v, want Bob", parts[1].Data)
+	}
+	content, err := parts[2].File.Content()
+	if err != nil || string(content) != "name,date\nCarol,12-01\n" {
+		t.Errorf("file content = %q, %v", content, err)
+	}
+	if string(req.ID) != "42" {
+		t.Errorf("ID = %s, want 42", req.ID)
+	}
+	if params.EffectiveTaskID() == "" || params.EffectiveContextID() != "" {
+		t.Errorf("task %q, context %q; want only the task", params.EffectiveTaskID(), params.EffectiveContextID())
+	}
+}
+
+func TestTaskResponseRoundTrip(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "response_task.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded struct {
+		ID     json.RawMessage `json:"id"`
+		Result Task            `json:"result"`
+	}
+	if err := json.Unmarshal(body, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(NewResponse(recorded.ID, recorded.Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameJSON(t, encoded, body)
+}
+
+func TestResponseMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Response
+		want     string
+	}{
+		{"result", NewResponse(json.RawMessage(`"abc"`), map[string]int{"n": 1}), `{"jsonrpc":"2.0","id":"abc","result":{"n":1}}`},
+		{"null result", NewResponse(json.RawMessage(`7`), nil), `{"jsonrpc":"2.0","id":7,"result":null}`},
+		{"error", NewErrorResponse(json.RawMessage(`7`), MethodNotFound("x")), `{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"Method not found: x"}}`},
+		{"error without an ID", NewErrorResponse(nil, ParseError("bad")), `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error - bad"}}`},
+		{"error wins over result", Response{JSONRPC: Version, ID: json.RawMessage(`1`), Result: "ignored", Error: TaskNotFound("t")}, `{"jsonrpc":"2.0","id":1,"error":{"code":-32001,"message":"Task not found: t"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		wantID string
+	}{
+		{"truncated JSON", `{"jsonrpc": "2.0", "id": 1,`, CodeParseError, ""},
+		{"empty body", ``, CodeParseError, ""},
+		{"not JSON", `hello`, CodeParseError, ""},
+		{"batch", `[{"jsonrpc": "2.0", "id": 1, "method": "tasks/get"}]`, CodeInvalidRequest, ""},
+		{"string", `"message/send"`, CodeInvalidRequest, ""},
+		{"wrong version", `{"jsonrpc": "1.0", "id": 1, "method": "tasks/get"}`, CodeInvalidRequest, "1"},
+		{"missing version", `{"id": "a", "method": "tasks/get"}`, CodeInvalidRequest, `"a"`},
+		{"missing method", `{"jsonrpc": "2.0", "id": 1}`, CodeInvalidRequest, "1"},
+		{"method not a string", `{"jsonrpc": "2.0", "id": 1, "method": 5}`, CodeInvalidRequest, "1"},
+		{"object ID", `{"jsonrpc": "2.0", "id": {"n": 1}, "method": "tasks/get"}`, CodeInvalidRequest, ""},
+		{"boolean ID", `{"jsonrpc": "2.0", "id": true, "method": "tasks/get"}`, CodeInvalidRequest, ""},
+		{"unknown field", `{"jsonrpc": "2.0", "id": 1, "method": "tasks/get", "extra": 1}`, CodeInvalidRequest, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rpcErr := DecodeRequest([]byte(tt.body))
+			if rpcErr == nil {
+				t.Fatalf("DecodeRequest(%s) succeeded, want code %d", tt.body, tt.code)
+			}
+			if rpcErr.Code != tt.code {
+				t.Errorf("code = %d (%s), want %d", rpcErr.Code, rpcErr.Message, tt.code)
+			}
+			if string(req.ID) != tt.wantID {
+				t.Errorf("ID = %s, want %q", req.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestIDs(t *testing.T) {
+	for _, id := range []string{`1`, `-3`, `"abc"`, `null`} {
+		req, rpcErr := DecodeRequest([]byte(`{"jsonrpc": "2.0", "id": ` + id + `, "method": "tasks/get"}`))
+		if rpcErr != nil {
+			t.Errorf("ID %s: %v", id, rpcErr)
+			continue
+		}
+		if string(req.ID) != id {
+			t.Errorf("ID = %s, want %s", req.ID, id)
+		}
+	}
+}
+
+func TestDecodeParamsErrors(t *testing.T) {
+	const message = `"message": {"role": "user", "parts": [{"kind": "text", "text": "hi"}]}`
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		detail string
+	}{
+		{"missing params", MethodMessageSend, ``, "params are required"},
+		{"null params", MethodMessageSend, `null`, "params are required"},
+		{"params not an object", MethodMessageSend, `[1]`, "cannot unmarshal"},
+		{"wrong type", MethodMessageSend, `{"message": {"role": "user", "parts": "hi"}}`, "must be an array"},
+		{"wrong role", MethodMessageSend, `{"message": {"role": "bot", "parts": [{"kind": "text", "text": "hi"}]}}`, "role"},
+		{"no parts", MethodMessageSend, `{"message": {"role": "user", "parts": []}}`, "no parts"},
+		{"text part without text", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "text"}]}}`, `needs "text"`},
+		{"part without kind", MethodMessageSend, `{"message": {"role": "user", "parts": [{"text": "hi"}]}}`, `missing its "kind"`},
+		{"unknown part kind", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "image", "text": "hi"}]}}`, `unknown part kind "image"`},
+		{"data part without data", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "data"}]}}`, `needs "data"`},
+		{"data part with null data", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "data", "data": null}]}}`, `needs "data"`},
+		{"file with bytes and uri", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "file", "file": {"bytes": "aGk=", "uri": "http://x"}}]}}`, `either "bytes" or "uri"`},
+		{"file bytes not base64", MethodMessageSend, `{"message": {"role": "user", "parts": [{"kind": "file", "file": {"bytes": "not base64!"}}]}}`, "base64"},
+		{"negative historyLength", MethodMessageSend, `{` + message + `, "configuration": {"historyLength": -1}}`, "negative"},
+		{"push config without url", MethodMessageSend, `{` + message + `, "configuration": {"pushNotificationConfig": {"token": "t"}}}`, "url is required"},
+		{"tasks/get without id", MethodTasksGet, `{"historyLength": 1}`, "task id is required"},
+		{"tasks/get historyLength as a string", MethodTasksGet, `{"id": "t", "historyLength": "2"}`, "must be a number"},
+		{"push config set without task", MethodPushConfigSet, `{"pushNotificationConfig": {"url": "http://x"}}`, "taskId is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{JSONRPC: Version, ID: json.RawMessage(`1`), Method: tt.method, Params: json.RawMessage(tt.params)}
+			rpcErr := req.DecodeParams(paramsFor(t, tt.method))
+			if rpcErr == nil {
+				t.Fatalf("DecodeParams(%s) succeeded", tt.params)
+			}
+			if rpcErr.Code != CodeInvalidParams {
+				t.Errorf("code = %d, want %d", rpcErr.Code, CodeInvalidParams)
+			}
+			if !strings.Contains(rpcErr.Message, tt.detail) {
+				t.Errorf("message = %q, want it to mention %q", rpcErr.Message, tt.detail)
+			}
+		})
+	}
+}
+
+func TestDecodeParamsIgnoresUnknownFields(t *testing.T) {
+	params := `{
+		"message": {"role": "user", "parts": [{"kind": "text", "text": "hi", "format": "html"}], "sender": "x"},
+		"configuration": {"blocking": true, "stream": true},
+		"extra": 1
+	}`
+	req := Request{JSONRPC: Version, ID: json.RawMessage(`1`), Method: MethodMessageSend, Params: json.RawMessage(params)}
+
+	var decoded MessageSendParams
+	if rpcErr := req.DecodeParams(&decoded); rpcErr != nil {
+		t.Fatalf("DecodeParams: %v", rpcErr)
+	}
+	if decoded.Message.Text() != "hi" || decoded.Configuration.Blocking == nil || !*decoded.Configuration.Blocking {
+		t.Errorf("decoded %+v, want the known fields read", decoded)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		code int
+	}{
+		{ParseError("x"), -32700},
+		{InvalidRequest("x"), -32600},
+		{MethodNotFound("x"), -32601},
+		{InvalidParams("x"), -32602},
+		{InternalError("x"), -32603},
+		{TaskNotFound("x"), -32001},
+		{TaskNotCancelable("x"), -32002},
+		{PushNotSupported(), -32003},
+	}
+	for _, tt := range tests {
+		if tt.err.Code != tt.code {
+			t.Errorf("%q has code %d, want %d", tt.err.Message, tt.err.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// States of an A2A task
+const (
+	StateSubmitted     = "submitted"
+	StateWorking       = "working"
+	StateInputRequired = "input-required"
+	StateCompleted     = "completed"
+	StateFailed        = "failed"
+	StateCanceled      = "canceled"
+)
+
+// Roles of a message's sender
+const (
+	RoleUser  = "user"
+	RoleAgent = "agent"
+)
+
+// Kinds of message part
+const (
+	PartText = "text"
+	PartData = "data"
+	PartFile = "file"
+)
+
+// Terminal reports whether a task in this state can't change any more
+func Terminal(state string) bool {
+	return state == StateCompleted || state == StateFailed || state == StateCanceled
+}
+
+// Part is one piece of a message or artifact: text, structured data or a
+// file. Data holds any JSON value: Telex sends the conversation history as
+// a data part with an array.
+type Part struct {
+	Kind     string
+	Text     string
+	Data     any
+	File     *File
+	Metadata map[string]any
+}
+
+// File is the content of a file part, sent inline as base64 bytes or as a
+// URI to fetch it from
+type File struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// Content decodes the file's inline bytes
+func (f File) Content() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(f.Bytes)
+}
+
+// TextPart creates a text part
+func TextPart(text string) Part {
+	return Part{Kind: PartText, Text: text}
+}
+
+// DataPart creates a structured data part
+func DataPart(data map[string]any) Part {
+	return Part{Kind: PartData, Data: data}
+}
+
+// TextParts wraps text in a single text part
+func TextParts(text string) []Part {
+	return []Part{TextPart(text)}
+}
+
+// partJSON is the wire form of every kind of part
+type partJSON struct {
+	Kind     string          `json:"kind"`
+	Text     *string         `json:"text,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	File     *File           `json:"file,omitempty"`
+	Metadata map[string]any  `json:"metadata,omitempty"`
+}
+
+// MarshalJSON writes only the fields of the part's kind
+func (p Part) MarshalJSON() ([]byte, error) {
+	out := partJSON{Kind: p.Kind, Metadata: p.Metadata}
+	switch p.Kind {
+	case PartText:
+		out.Text = &p.Text
+	case PartData:
+		data, err := json.Marshal(p.Data)
+		if err != nil {
+			return nil, err
+		}
+		out.Data = data
+	case PartFile:
+		out.File = p.File
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a part, requiring a known kind and the field that
+// goes with it
+func (p *Part) UnmarshalJSON(data []byte) error {
+	var in partJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*p = Part{Kind: in.Kind, Metadata: in.Metadata}
+	switch in.Kind {
+	case PartText:
+		if in.Text == nil {
+			return errors.New(`a text part needs "text"`)
+		}
+		p.Text = *in.Text
+	case PartData:
+		if err := json.Unmarshal(in.Data, &p.Data); err != nil || p.Data == nil {
+			return errors.New(`a data part needs "data"`)
+		}
+	case PartFile:
+		if in.File == nil || (in.File.Bytes == "") == (in.File.URI == "") {
+			return errors.New(`a file part needs a "file" with either "bytes" or "uri"`)
+		}
+		if _, err := in.File.Content(); in.File.Bytes != "" && err != nil {
+			return errors.New("file bytes must be base64")
+		}
+		p.File = in.File
+	case "":
+		return errors.New(`part is missing its "kind"`)
+	default:
+		return fmt.Errorf("unknown part kind %q", in.Kind)
+	}
+	return nil
+}
+
+// Message is one turn of a conversation with the agent
+type Message struct {
+	Kind      string         `json:"kind"`
+	MessageID string         `json:"messageId"`
+	Role      string         `json:"role"`
+	Parts     []Part         `json:"parts"`
+	TaskID    string         `json:"taskId,omitempty"`
+	ContextID string         `json:"contextId,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	// ReferenceTaskIDs and Extensions are accepted as A2A defines them but
+	// not used
+	ReferenceTaskIDs []string `json:"referenceTaskIds,omitempty"`
+	Extensions       []string `json:"extensions,omitempty"`
+}
+
+// NewMessage creates a text message from role
+func NewMessage(role, text string) Message {
+	return Message{Kind: "message", MessageID: uuid.New().String(), Role: role, Parts: TextParts(text)}
+}
+
+// Text joins the message's text parts, one per line
+func (m Message) Text() string {
+	var texts []string
+	for _, part := range m.Parts {
+		if part.Kind == PartText && strings.TrimSpace(part.Text) != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Validate checks a message received from a client. The kind and message
+// ID may be left out; the role and at least one part may not.
+func (m *Message) Validate() error {
+	switch {
+	case m.Kind != "" && m.Kind != "message":
+		return fmt.Errorf(`message kind must be "message", not %q`, m.Kind)
+	case m.Role != RoleUser && m.Role != RoleAgent:
+		return fmt.Errorf(`message role must be %q or %q`, RoleUser, RoleAgent)
+	case len(m.Parts) == 0:
+		return errors.New("message has no parts")
+	}
+	if m.Kind == "" {
+		m.Kind = "message"
+	}
+	return nil
+}
+
+// Artifact is a result a task produced
+type Artifact struct {
+	ArtifactID string `json:"artifactId"`
+	Name       string `json:"name,omitempty"`
+	Parts      []Part `json:"parts"`
+}
+
+// NewArtifact creates a text artifact
+func NewArtifact(name, text string) Artifact {
+	return Artifact{ArtifactID: uuid.New().String(), Name: name, Parts: TextParts(text)}
+}
+
+// Status is a task's state and, when there is one, the agent's latest message
+type Status struct {
+	State     string    `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Task is an A2A task
+type Task struct {
+	ID        string         `json:"id"`
+	ContextID string         `json:"contextId"`
+	Kind      string         `json:"kind"`
+	Status    Status         `json:"status"`
+	History   []Message      `json:"history,omitempty"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// StatusUpdateEvent is a message/stream event announcing a new state.
+// Final is set on the last event of the stream.
+type StatusUpdateEvent struct {
+	TaskID    string `json:"taskId"`
+	ContextID string `json:"contextId"`
+	Kind      string `json:"kind"`
+	Status    Status `json:"status"`
+	Final     bool   `json:"final"`
+}
+
+// NewStatusUpdate creates the status event for the task's current state
+func NewStatusUpdate(t Task, final bool) StatusUpdateEvent {
+	return StatusUpdateEvent{TaskID: t.ID, ContextID: t.ContextID, Kind: "status-update", Status: t.Status, Final: final}
+}
+
+// ArtifactUpdateEvent is a message/stream event carrying an artifact or a
+// piece of one. With Append set the parts add to the artifact sent before
+// under the same ID; otherwise they replace it.
+type ArtifactUpdateEvent struct {
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Kind      string   `json:"kind"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append"`
+	LastChunk bool     `json:"lastChunk"`
+}
+
+// NewArtifactUpdate creates the event for an artifact of the task
+func NewArtifactUpdate(t Task, artifact Artifact, appendParts, lastChunk bool) ArtifactUpdateEvent {
+	return ArtifactUpdateEvent{TaskID: t.ID, ContextID: t.ContextID, Kind: "artifact-update", Artifact: artifact, Append: appendParts, LastChunk: lastChunk}
+}
+
+// MessageSendParams are the params of message/send and message/stream
+type MessageSendParams struct {
+	Message       Message                   `json:"message"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
+	Metadata      map[string]any            `json:"metadata,omitempty"`
+	// ContextID and TaskID are read from here too when the message leaves
+	// them out, as some clients send them next to it
+	ContextID string `json:"contextId,omitempty"`
+	TaskID    string `json:"taskId,omitempty"`
+}
+
+// MessageSendConfiguration tunes how message/send answers
+type MessageSendConfiguration struct {
+	AcceptedOutputModes []string `json:"acceptedOutputModes,omitempty"`
+	// HistoryLength limits the history in the returned task
+	HistoryLength *int `json:"historyLength,omitempty"`
+	// PushNotificationConfig registers a push notification URL for the task
+	PushNotificationConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+	// Blocking false returns the task while it is still working
+	Blocking *bool `json:"blocking,omitempty"`
+}
+
+func (p *MessageSendParams) Validate() error {
+	if err := p.Message.Validate(); err != nil {
+		return err
+	}
+	if c := p.Configuration; c != nil {
+		if c.HistoryLength != nil && *c.HistoryLength < 0 {
+			return errors.New("historyLength can't be negative")
+		}
+		if c.PushNotificationConfig != nil {
+			if err := c.PushNotificationConfig.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EffectiveContextID returns the message's context ID, or the one next to it
+func (p MessageSendParams) EffectiveContextID() string {
+	if p.Message.ContextID != "" {
+		return p.Message.ContextID
+	}
+	return p.ContextID
+}
+
+// EffectiveTaskID returns the message's task ID, or the one next to it
+func (p MessageSendParams) EffectiveTaskID() string {
+	if p.Message.TaskID != "" {
+		return p.Message.TaskID
+	}
+	return p.TaskID
+}
+
+// TaskQueryParams are the params of tasks/get
+type TaskQueryParams struct {
+	ID            string         `json:"id"`
+	HistoryLength *int           `json:"historyLength,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+func (p *TaskQueryParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("task id is required")
+	}
+	if p.HistoryLength != nil && *p.HistoryLength < 0 {
+		return errors.New("historyLength can't be negative")
+	}
+	return nil
+}
+
+// TaskIDParams are the params of tasks/cancel
+type TaskIDParams struct {
+	ID       string         `json:"id"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+func (p *TaskIDParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("task id is required")
+	}
+	return nil
+}
+
+// PushNotificationConfig is where the client wants to be told about a task
+type PushNotificationConfig struct {
+	ID    string `json:"id,omitempty"`
+	URL   string `json:"url"`
+	Token string `json:"token,omitempty"`
+	// Authentication tells Hazel how to authenticate to the URL
+	Authentication *PushAuthentication `json:"authentication,omitempty"`
+}
+
+// PushAuthentication names the schemes the push URL accepts and the
+// credentials to use. Credentials are never sent back to clients.
+type PushAuthentication struct {
+	Schemes     []string `json:"schemes"`
+	Credentials string   `json:"credentials,omitempty"`
+}
+
+func (c *PushNotificationConfig) Validate() error {
+	if c.URL == "" {
+		return errors.New("pushNotificationConfig.url is required")
+	}
+	return nil
+}
+
+// TaskPushNotificationConfig is a task's push notification config: the
+// params of tasks/pushNotificationConfig/set and the result of get and list
+type TaskPushNotificationConfig struct {
+	TaskID                 string                 `json:"taskId"`
+	PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig"`
+}
+
+func (p *TaskPushNotificationConfig) Validate() error {
+	if p.TaskID == "" {
+		return errors.New("taskId is required")
+	}
+	return p.PushNotificationConfig.Validate()
+}
+
+// PushNotificationConfigParams are the params of
+// tasks/pushNotificationConfig/get, /list and /delete
+type PushNotificationConfigParams struct {
+	ID                       string         `json:"id"`
+	PushNotificationConfigID string         `json:"pushNotificationConfigId,omitempty"`
+	Metadata                 map[string]any `json:"metadata,omitempty"`
+}
+
+func (p *PushNotificationConfigParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("task id is required")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Defaults for PushSender
+const (
+	DefaultPushAttempts = 3
+	DefaultPushBackoff  = time.Second
+)
+
+// ErrNoSubscribers is returned by PushNotifier when the notification's
+// tenant hasn't registered any push notification config
+var ErrNoSubscribers = errors.New("no push notification configs for tenant")
+
+// PushSender POSTs task updates to the URLs A2A clients registered with
+// tasks/pushNotificationConfig/set, retrying failed deliveries
+type PushSender struct {
+	Client *http.Client
+	// Attempts is how many times a delivery is tried in all
+	Attempts int
+	// Backoff is the wait before the first retry; it doubles after each one
+	Backoff time.Duration
+	// AllowPrivateHosts lets push URLs reach loopback, private and
+	// link-local addresses, e.g. for a client on the same network
+	AllowPrivateHosts bool
+}
+
+// ErrPrivateHost is returned for a push URL that points at a loopback,
+// private or link-local address while those aren't allowed
+var ErrPrivateHost = errors.New("push notification URLs can't point at loopback, private or link-local addresses")
+
+// NewPushSender creates a sender. Zero attempts or backoff use the defaults.
+// Its client refuses to connect to private addresses unless
+// AllowPrivateHosts is set, checking the address each host name resolves to.
+func NewPushSender(attempts int, backoff time.Duration) *PushSender {
+	if attempts <= 0 {
+		attempts = DefaultPushAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultPushBackoff
+	}
+	s := &PushSender{
+		Attempts: attempts,
+		Backoff:  backoff,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if s.AllowPrivateHosts {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+				return ErrPrivateHost
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	s.Client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return s
+}
+
+// CheckPushURL rejects a push URL naming a loopback, private or link-local
+// host. Host names are checked again when the sender connects, since they
+// may resolve to such an address.
+func CheckPushURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateHost
+	}
+	if ip := net.ParseIP(host); ip != nil && privateIP(ip) {
+		return ErrPrivateHost
+	}
+	return nil
+}
+
+// privateIP reports whether ip is only reachable from this host or its
+// local network
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// Send delivers the task to the config's URL. Network errors, 429s and 5xx
+// responses are retried with exponential backoff; other failures, and URLs
+// refused with ErrPrivateHost, aren't.
+func (s *PushSender) Send(ctx context.Context, config store.PushConfig, t protocol.Task) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode task %s: %w", t.ID, err)
+	}
+
+	wait := s.Backoff
+	for attempt := 1; ; attempt++ {
+		err = s.post(ctx, config, body)
+		var status *pushStatusError
+		retryable := err != nil && !errors.Is(err, ErrPrivateHost) && (!errors.As(err, &status) || status.retryable())
+		if !retryable || attempt >= s.Attempts {
+			break
+		}
+
+		log.Printf("Push notification to %s failed (attempt %d of %d), retrying in %s: %v", config.URL, attempt, s.Attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push task %s to %s: %w", t.ID, config.URL, err)
+	}
+	return nil
+}
+
+// post makes one delivery attempt
+func (s *PushSender) post(ctx context.Context, config store.PushConfig, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Token != "" {
+		req.Header.Set("X-A2A-Notification-Token", config.Token)
+	}
+	if config.Credentials != "" && slices.ContainsFunc(config.AuthSchemes, SupportedAuthScheme) {
+		req.Header.Set("Authorization", "Bearer "+config.Credentials)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &pushStatusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// SupportedAuthScheme reports whether Hazel can authenticate to a push
+// notification URL with the scheme. Only Bearer is supported.
+func SupportedAuthScheme(scheme string) bool {
+	return strings.EqualFold(scheme, "Bearer")
+}
+
+// pushStatusError is a push notification rejected by the client's server
+type pushStatusError struct {
+	code int
+}
+
+func (e *pushStatusError) Error() string {
+	return fmt.Sprintf("push notification URL returned status %d", e.code)
+}
+
+func (e *pushStatusError) retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// PushNotifier delivers reminders to the A2A clients that registered push
+// notification configs for the reminder's tenant. Each reminder becomes a
+// completed task in the context the config was registered from.
+type PushNotifier struct {
+	configs *store.PushConfigs
+	tasks   *task.Store
+	sender  *PushSender
+}
+
+func NewPushNotifier(configs *store.PushConfigs, tasks *task.Store, sender *PushSender) *PushNotifier {
+	return &PushNotifier{configs: configs, tasks: tasks, sender: sender}
+}
+
+func (p *PushNotifier) Notify(ctx context.Context, n Notification) error {
+	configs := p.configs.ForTenant(n.Tenant)
+	if len(configs) == 0 {
+		return ErrNoSubscribers
+	}
+
+	var errs []error
+	for _, config := range configs {
+		t := p.tasks.Record(n.Tenant, config.ContextID, protocol.NewMessage(protocol.RoleAgent, n.Text), protocol.NewArtifact(n.Kind, n.Text))
+		if err := p.sender.Send(ctx, config, t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(configs) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Printf("Failed to push %s notification: %v", n.Kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pushReceiver is a local push notification URL that answers with the
+// scripted statuses in turn, then 200. A status of 0 drops the connection.
+type pushReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	tasks    []protocol.Task
+}
+
+func newPushReceiver(t *testing.T, statuses ...int) *pushReceiver {
+	t.Helper()
+	r := &pushReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *pushReceiver) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.requests = append(r.requests, req)
+	var t protocol.Task
+	if json.NewDecoder(req.Body).Decode(&t) == nil {
+		r.tasks = append(r.tasks, t)
+	}
+	r.mu.Unlock()
+
+	if status == 0 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	w.WriteHeader(status)
+}
+
+func (r *pushReceiver) attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func newTestPushSender(attempts int) *PushSender {
+	sender := NewPushSender(attempts, time.Millisecond)
+	sender.Client = &http.Client{Timeout: 2 * time.Second}
+	return sender
+}
+
+func TestPushSenderRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		attempts     int
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"delivered first time", nil, 3, false, 1},
+		{"retries a 429", []int{http.StatusTooManyRequests}, 3, false, 2},
+		{"retries a 5xx", []int{http.StatusInternalServerError, http.StatusBadGateway}, 3, false, 3},
+		{"retries a dropped connection", []int{0}, 3, false, 2},
+		{"gives up after the limit", []int{503, 503, 503, 503}, 3, true, 3},
+		{"a single attempt", []int{503}, 1, true, 1},
+		{"doesn't retry a 4xx", []int{http.StatusBadRequest}, 3, true, 1},
+		{"doesn't retry a 401", []int{http.StatusUnauthorized}, 3, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newPushReceiver(t, tt.statuses...)
+			sender := newTestPushSender(tt.attempts)
+
+			err := sender.Send(context.Background(), store.PushConfig{URL: receiver.URL}, protocol.Task{ID: "task-1", Kind: "task"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send error = %v, want error: %v", err, tt.wantErr)
+			}
+			if got := receiver.attempts(); got != tt.wantAttempts {
+				t.Errorf("receiver got %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPushSenderUnreachable(t *testing.T) {
+	receiver := newPushReceiver(t)
+	url := receiver.URL
+	receiver.Close()
+
+	err := newTestPushSender(2).Send(context.Background(), store.PushConfig{URL: url}, protocol.Task{ID: "task-1"})
+	if err == nil {
+		t.Error("Send to a closed server succeeded")
+	}
+}
+
+func TestPushSenderStopsWhenCanceled(t *testing.T) {
+	receiver := newPushReceiver(t, 503, 503, 503)
+	sender := NewPushSender(3, time.Hour)
+	sender.AllowPrivateHosts = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for receiver.attempts() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	if err := sender.Send(ctx, store.PushConfig{URL: receiver.URL}, protocol.Task{ID: "task-1"}); err != context.Canceled {
+		t.Errorf("Send error = %v, want context.Canceled", err)
+	}
+}
+
+func TestPushSenderHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     store.PushConfig
+		wantToken  string
+		wantBearer string
+	}{
+		{"no auth", store.PushConfig{}, "", ""},
+		{"token", store.PushConfig{Token: "secret-token"}, "secret-token", ""},
+		{"bearer", store.PushConfig{AuthSchemes: []string{"Bearer"}, Credentials: "creds"}, "", "Bearer creds"},
+		{"bearer in any case", store.PushConfig{AuthSchemes: []string{"Basic", "bearer"}, Credentials: "creds"}, "", "Bearer creds"},
+		{"unsupported scheme", store.PushConfig{AuthSchemes: []string{"Basic"}, Credentials: "creds"}, "", ""},
+		{"token and bearer", store.PushConfig{Token: "t", AuthSchemes: []string{"Bearer"}, Credentials: "c"}, "t", "Bearer c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newPushReceiver(t)
+			tt.config.URL = receiver.URL
+
+			if err := newTestPushSender(1).Send(context.Background(), tt.config, protocol.Task{ID: "task-1"}); err != nil {
+				t.Fatal(err)
+			}
+
+			req := receiver.requests[0]
+			if got := req.Header.Get("X-A2A-Notification-Token"); got != tt.wantToken {
+				t.Errorf("X-A2A-Notification-Token = %q, want %q", got, tt.wantToken)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.wantBearer {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantBearer)
+			}
+			if got := req.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if receiver.tasks[0].ID != "task-1" {
+				t.Errorf("pushed task %q, want task-1", receiver.tasks[0].ID)
+			}
+		})
+	}
+}
+
+func TestPushNotifier(t *testing.T) {
+	first, second := newPushReceiver(t), newPushReceiver(t)
+	configs, err := store.NewPushConfigs(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []store.PushConfig{
+		{TaskID: "a", ContextID: "ctx-1", Tenant: "team", URL: first.URL},
+		{TaskID: "b", ContextID: "ctx-2", Tenant: "team", URL: second.URL},
+		{TaskID: "c", ContextID: "ctx-3", Tenant: "other", URL: first.URL + "/other"},
+	} {
+		if _, err := configs.Set(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks := task.NewStore(time.Hour)
+	notifier := NewPushNotifier(configs, tasks, newTestPushSender(1))
+
+	err = notifier.Notify(context.Background(), Notification{Kind: KindWish, Tenant: "team", Text: "Happy birthday, Ada!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		receiver  *pushReceiver
+		contextID string
+	}{{first, "ctx-1"}, {second, "ctx-2"}} {
+		if len(tt.receiver.tasks) != 1 {
+			t.Fatalf("receiver for %s got %d tasks, want 1", tt.contextID, len(tt.receiver.tasks))
+		}
+		pushed := tt.receiver.tasks[0]
+		if pushed.ContextID != tt.contextID || pushed.Status.State != protocol.StateCompleted {
+			t.Errorf("pushed task in %s, state %s; want %s, completed", pushed.ContextID, pushed.Status.State, tt.contextID)
+		}
+		if pushed.Status.Message == nil || pushed.Status.Message.Text() != "Happy birthday, Ada!" {
+			t.Errorf("pushed task message = %+v, want the wish", pushed.Status.Message)
+		}
+
+		// Each push is a task the client can fetch afterwards
+		recorded, err := tasks.Get(pushed.ID, task.AllHistory)
+		if err != nil {
+			t.Errorf("pushed task %s was not recorded: %v", pushed.ID, err)
+		} else if recorded.ContextID != tt.contextID {
+			t.Errorf("recorded task in %s, want %s", recorded.ContextID, tt.contextID)
+		}
+	}
+	if first.tasks[0].ID == second.tasks[0].ID {
+		t.Error("both configs got the same task")
+	}
+}
+
+func TestPushNotifierErrors(t *testing.T) {
+	configs, err := store.NewPushConfigs(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier := NewPushNotifier(configs, task.NewStore(time.Hour), newTestPushSender(1))
+	n := Notification{Kind: KindWish, Tenant: "team", Text: "Happy birthday!"}
+
+	if err := notifier.Notify(context.Background(), n); err != ErrNoSubscribers {
+		t.Errorf("without configs: err = %v, want ErrNoSubscribers", err)
+	}
+
+	down, up := newPushReceiver(t, 500, 500), newPushReceiver(t)
+	if _, err := configs.Set(store.PushConfig{TaskID: "a", Tenant: "team", URL: down.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), n); err == nil {
+		t.Error("with every push failing: err = nil, want an error")
+	}
+
+	if _, err := configs.Set(store.PushConfig{TaskID: "b", Tenant: "team", URL: up.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), n); err != nil {
+		t.Errorf("with one push working: err = %v, want nil", err)
+	}
+}
+
+func TestPushSenderRefusesPrivateHosts(t *testing.T) {
+	receiver := newPushReceiver(t)
+	sender := NewPushSender(3, time.Millisecond)
+
+	err := sender.Send(context.Background(), store.PushConfig{URL: receiver.URL}, protocol.Task{ID: "task-1"})
+	if !errors.Is(err, ErrPrivateHost) {
+		t.Errorf("Send to %s: err = %v, want ErrPrivateHost", receiver.URL, err)
+	}
+	if n := receiver.attempts(); n != 0 {
+		t.Errorf("receiver got %d attempts, want none", n)
+	}
+
+	sender.AllowPrivateHosts = true
+	if err := sender.Send(context.Background(), store.PushConfig{URL: receiver.URL}, protocol.Task{ID: "task-1"}); err != nil {
+		t.Errorf("Send with private hosts allowed: %v", err)
+	}
+}
+
+func TestCheckPushURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{"https://ping.telex.im/v1/a2a/webhooks/1", false},
+		{"https://93.184.215.14/hook", false},
+		{"http://localhost:8080/hook", true},
+		{"http://api.localhost/hook", true},
+		{"http://127.0.0.1/hook", true},
+		{"http://10.1.2.3/hook", true},
+		{"http://172.16.0.1/hook", true},
+		{"http://192.168.1.10/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://[fe80::1]/hook", true},
+		{"http://[fd00::1]/hook", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+	}
+	for _, tt := range tests {
+		err := CheckPushURL(tt.url)
+		if got := errors.Is(err, ErrPrivateHost); got != tt.private {
+			t.Errorf("CheckPushURL(%q) = %v, want private: %v", tt.url, err, tt.private)
+		}
+	}
+}
@@ -2,78 +2,145 @@ package clients
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
 	"google.golang.org/genai"
 )
 
+// DefaultGeminiModel is used when no model is configured
+const DefaultGeminiModel = "gemini-2.0-flash-exp"
+
 type GeminiClient struct {
 	client *genai.Client
+	opts   Options
 }
 
-func NewGeminiClient() (*GeminiClient, error) {
-	ctx := context.Background()
-
+// NewGeminiClient creates a client for the Gemini API. The API key comes
+// from opts or the GEMINI_API_KEY environment variable.
+func NewGeminiClient(opts Options) (*GeminiClient, error) {
 	// Check if API key is set
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
+	if opts.APIKey == "" {
+		opts.APIKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if opts.APIKey == "" {
 		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
 	}
 
-	client, err := genai.NewClient(ctx, nil)
+	config := &genai.ClientConfig{APIKey: opts.APIKey}
+	if opts.BaseURL != "" {
+		config.HTTPOptions.BaseURL = opts.BaseURL
+	}
+	return NewGeminiClientWithConfig(config, opts)
+}
+
+// NewGeminiClientWithConfig creates a client from an explicit genai config,
+// e.g. one with its own HTTPClient or base URL
+func NewGeminiClientWithConfig(config *genai.ClientConfig, opts Options) (*GeminiClient, error) {
+	client, err := genai.NewClient(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
 
-	return &GeminiClient{client: client}, nil
+	if opts.Model == "" {
+		opts.Model = DefaultGeminiModel
+	}
+	return &GeminiClient{client: client, opts: opts}, nil
 }
 
-func (g *GeminiClient) GenerateBirthdayWish(name string, age int) (string, error) {
+func (g *GeminiClient) Name() string { return ProviderGemini }
+
+// GenerateWish asks Gemini to write the wish
+func (g *GeminiClient) GenerateWish(ctx context.Context, req WishRequest) (Wish, error) {
 	// Use timeout to prevent hanging
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, g.opts.timeout())
 	defer cancel()
 
-	prompt := fmt.Sprintf("Generate a warm and personalized birthday wish for %s who is turning %d years old. Make it heartfelt, positive, and celebratory. Keep it under 100 words.", name, age)
-
 	result, err := g.client.Models.GenerateContent(
 		ctx,
-		"gemini-2.0-flash-exp",
-		genai.Text(prompt),
-		nil,
+		g.opts.Model,
+		genai.Text(BuildPrompt(req)),
+		g.config(),
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate birthday wish: %w", err)
+		return Wish{}, fmt.Errorf("failed to generate birthday wish: %w", err)
 	}
 
 	if result.Text() == "" {
-		return fmt.Sprintf("🎉 Happy %d Birthday, %s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! May this new year bring you endless blessings and amazing adventures! 🌟", age, name), nil
+		return Wish{}, errors.New("gemini returned an empty wish")
 	}
 
-	return result.Text(), nil
+	return Wish{Text: EnforceLength(result.Text(), req.MaxWords, req.Language), Source: ProviderGemini, Model: g.opts.Model}, nil
 }
 
-func (g *GeminiClient) GenerateGenericBirthdayWish(name string) (string, error) {
+// StreamWish asks Gemini to write the wish, passing each piece of text to
+// onChunk as it arrives. The returned wish is the whole text, cut to length.
+func (g *GeminiClient) StreamWish(ctx context.Context, req WishRequest, onChunk func(string)) (Wish, error) {
 	// Use timeout to prevent hanging
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, g.opts.timeout())
 	defer cancel()
 
-	prompt := fmt.Sprintf("Generate a warm and personalized birthday wish for %s. Make it heartfelt, positive, and celebratory. Keep it under 100 words.", name)
+	var text strings.Builder
+	for result, err := range g.client.Models.GenerateContentStream(ctx, g.opts.Model, genai.Text(BuildPrompt(req)), g.config()) {
+		if err != nil {
+			return Wish{}, fmt.Errorf("failed to stream birthday wish: %w", err)
+		}
+		if chunk := result.Text(); chunk != "" {
+			text.WriteString(chunk)
+			onChunk(chunk)
+		}
+	}
 
-	result, err := g.client.Models.GenerateContent(
-		ctx,
-		"gemini-2.0-flash-exp",
-		genai.Text(prompt),
-		nil,
-	)
+	if strings.TrimSpace(text.String()) == "" {
+		return Wish{}, errors.New("gemini returned an empty wish")
+	}
+	return Wish{Text: EnforceLength(text.String(), req.MaxWords, req.Language), Source: ProviderGemini, Model: g.opts.Model}, nil
+}
+
+// GenerateWishes asks Gemini for n candidate wishes in one request
+func (g *GeminiClient) GenerateWishes(ctx context.Context, req WishRequest, n int) ([]Wish, error) {
+	// Use timeout to prevent hanging
+	ctx, cancel := context.WithTimeout(ctx, g.opts.timeout())
+	defer cancel()
+
+	config := g.config()
+	config.CandidateCount = int32(n)
+	result, err := g.client.Models.GenerateContent(ctx, g.opts.Model, genai.Text(BuildPrompt(req)), config)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate birthday wish: %w", err)
+		return nil, fmt.Errorf("failed to generate birthday wishes: %w", err)
 	}
 
-	if result.Text() == "" {
-		return fmt.Sprintf("🎉 Happy Birthday, %s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! May this year bring you endless blessings and amazing adventures! 🌟", name), nil
+	var wishes []Wish
+	for _, candidate := range result.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range candidate.Content.Parts {
+			if !part.Thought {
+				text.WriteString(part.Text)
+			}
+		}
+		if strings.TrimSpace(text.String()) != "" {
+			wishes = append(wishes, Wish{Text: EnforceLength(text.String(), req.MaxWords, req.Language), Source: ProviderGemini, Model: g.opts.Model})
+		}
+	}
+	if len(wishes) == 0 {
+		return nil, errors.New("gemini returned no wishes")
 	}
+	return wishes, nil
+}
 
-	return result.Text(), nil
+// config applies the configured sampling options to a request
+func (g *GeminiClient) config() *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{}
+	if g.opts.Temperature > 0 {
+		config.Temperature = genai.Ptr(float32(g.opts.Temperature))
+	}
+	if g.opts.MaxTokens > 0 {
+		config.MaxOutputTokens = int32(g.opts.MaxTokens)
+	}
+	return config
 }
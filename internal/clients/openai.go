@@ -0,0 +1,125 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Defaults for the OpenAI-compatible provider
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIClient writes wishes with any server speaking the OpenAI chat
+// completions API, such as OpenAI itself, llama.cpp or Ollama
+type OpenAIClient struct {
+	opts   Options
+	Client *http.Client
+}
+
+func NewOpenAIClient(opts Options) *OpenAIClient {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultOpenAIBaseURL
+	}
+	if opts.Model == "" {
+		opts.Model = DefaultOpenAIModel
+	}
+	return &OpenAIClient{
+		opts:   opts,
+		Client: &http.Client{Timeout: opts.timeout()},
+	}
+}
+
+func (o *OpenAIClient) Name() string { return ProviderOpenAI }
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	N           int           `json:"n,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// GenerateWish asks the chat completions endpoint to write the wish
+func (o *OpenAIClient) GenerateWish(ctx context.Context, req WishRequest) (Wish, error) {
+	wishes, err := o.complete(ctx, req, 0)
+	if err != nil {
+		return Wish{}, err
+	}
+	return wishes[0], nil
+}
+
+// GenerateWishes asks the chat completions endpoint for n candidate wishes.
+// Servers that ignore "n" return just one.
+func (o *OpenAIClient) GenerateWishes(ctx context.Context, req WishRequest, n int) ([]Wish, error) {
+	return o.complete(ctx, req, n)
+}
+
+// complete sends the wish prompt and returns every non-empty choice
+func (o *OpenAIClient) complete(ctx context.Context, req WishRequest, n int) ([]Wish, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:       o.opts.Model,
+		Messages:    []chatMessage{{Role: "user", Content: BuildPrompt(req)}},
+		Temperature: o.opts.Temperature,
+		MaxTokens:   o.opts.MaxTokens,
+		N:           n,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode wish request: %w", err)
+	}
+
+	url := strings.TrimSuffix(o.opts.BaseURL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build wish request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.opts.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.opts.APIKey)
+	}
+
+	resp, err := o.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate birthday wish: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("chat completions returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode wish response: %w", err)
+	}
+
+	var wishes []Wish
+	for _, choice := range result.Choices {
+		if strings.TrimSpace(choice.Message.Content) != "" {
+			wishes = append(wishes, Wish{Text: EnforceLength(choice.Message.Content, req.MaxWords, req.Language), Source: ProviderOpenAI, Model: o.opts.Model})
+		}
+	}
+	if len(wishes) == 0 {
+		return nil, errors.New("chat completions returned an empty wish")
+	}
+	return wishes, nil
+}
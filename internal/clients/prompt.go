@@ -0,0 +1,173 @@
+package clients
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tones a wish can be written in
+const (
+	ToneWarm   = "warm"
+	ToneFunny  = "funny"
+	ToneFormal = "formal"
+	TonePoetic = "poetic"
+)
+
+// Tones lists every supported tone
+var Tones = []string{ToneWarm, ToneFunny, ToneFormal, TonePoetic}
+
+// Limits on WishRequest.MaxWords
+const (
+	DefaultMaxWords = 100
+	MinWishWords    = 10
+	MaxWishWords    = 300
+)
+
+// languages maps the supported ISO 639-1 codes to their English names
+var languages = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"it": "Italian",
+	"pt": "Portuguese",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"sw": "Swahili",
+	"yo": "Yoruba",
+	"ha": "Hausa",
+	"ig": "Igbo",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"hi": "Hindi",
+	"ar": "Arabic",
+}
+
+// unspacedLanguages are written without spaces between words, so their
+// length is measured in characters, charsPerWord to each word allowed
+var unspacedLanguages = map[string]bool{"ja": true, "zh": true}
+
+const charsPerWord = 2
+
+// LanguageCode returns the code for a language given as a code ("es") or an
+// English name ("Spanish")
+func LanguageCode(language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if _, ok := languages[language]; ok {
+		return language, true
+	}
+	for code, name := range languages {
+		if strings.ToLower(name) == language {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+var toneStyles = map[string]string{
+	ToneWarm:   "warm, heartfelt and celebratory",
+	ToneFunny:  "funny and light-hearted, with a gentle joke or two, but still kind",
+	ToneFormal: "formal and respectful, suitable for a workplace",
+	TonePoetic: "poetic, with vivid imagery; it may rhyme",
+}
+
+// BuildPrompt is the instruction sent to language-model providers. req
+// should have been validated; empty options use their defaults.
+func BuildPrompt(req WishRequest) string {
+	tone := toneStyles[req.Tone]
+	if tone == "" {
+		tone = toneStyles[ToneWarm]
+	}
+	maxWords := req.MaxWords
+	if maxWords <= 0 {
+		maxWords = DefaultMaxWords
+	}
+
+	var b strings.Builder
+	b.WriteString("Write a birthday wish.\n")
+	switch {
+	case req.Name != "":
+		fmt.Fprintf(&b, "- Recipient: %s\n", req.Name)
+	case req.Relationship != "":
+		fmt.Fprintf(&b, "- Recipient: the sender's %s (don't use a name)\n", req.Relationship)
+	default:
+		b.WriteString("- Recipient: a friend (don't use a name)\n")
+	}
+	if req.Age > 0 {
+		fmt.Fprintf(&b, "- Turning: %d\n", req.Age)
+	}
+	if req.Name != "" && req.Relationship != "" {
+		fmt.Fprintf(&b, "- They are the sender's %s\n", req.Relationship)
+	}
+	if len(req.Interests) > 0 {
+		fmt.Fprintf(&b, "- Interests: %s\n", strings.Join(req.Interests, ", "))
+	}
+	if req.Notes != "" {
+		fmt.Fprintf(&b, "- Notes about them: %s\n", req.Notes)
+	}
+	fmt.Fprintf(&b, "- Tone: %s\n", tone)
+	if name, ok := languages[req.Language]; ok && req.Language != "en" {
+		fmt.Fprintf(&b, "- Language: write it in %s\n", name)
+	}
+	if unspacedLanguages[req.Language] {
+		fmt.Fprintf(&b, "- Length: at most %d characters\n", maxWords*charsPerWord)
+	} else {
+		fmt.Fprintf(&b, "- Length: at most %d words\n", maxWords)
+	}
+	if req.Feedback != "" {
+		fmt.Fprintf(&b, "- Feedback on earlier drafts: %s\n", req.Feedback)
+	}
+	if len(req.Avoid) > 0 {
+		b.WriteString("Don't repeat or closely paraphrase these earlier wishes:\n")
+		for _, draft := range req.Avoid {
+			fmt.Fprintf(&b, "- %q\n", draft)
+		}
+	}
+	b.WriteString("Reply with the wish only, without a title or quotation marks.")
+	return b.String()
+}
+
+// EnforceLength cuts text down to maxWords words (DefaultMaxWords when 0),
+// ending at the last full sentence when one fits and with "…" otherwise.
+// Text in a language written without spaces, such as Japanese or Chinese,
+// is cut to charsPerWord characters a word instead.
+func EnforceLength(text string, maxWords int, language string) string {
+	text = strings.TrimSpace(text)
+	if maxWords <= 0 {
+		maxWords = DefaultMaxWords
+	}
+	if unspacedLanguages[language] {
+		return enforceRunes(text, maxWords*charsPerWord)
+	}
+	words := strings.Fields(text)
+	if len(words) <= maxWords {
+		return text
+	}
+
+	cut := strings.Join(words[:maxWords], " ")
+	// Keep a full sentence if it leaves at least half the allowed words
+	if i := strings.LastIndexAny(cut, ".!?"); i >= 0 && len(strings.Fields(cut[:i+1])) >= maxWords/2 {
+		return cut[:i+1]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool { return unicode.IsPunct(r) && r != ')' }) + "…"
+}
+
+// enforceRunes is EnforceLength for text without spaces between words
+func enforceRunes(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+
+	cut := string(runes[:maxRunes])
+	// Keep a full sentence if it leaves at least half the allowed characters
+	if i := strings.LastIndexAny(cut, ".!?。！？"); i >= 0 {
+		_, size := utf8.DecodeRuneInString(cut[i:])
+		if sentence := cut[:i+size]; utf8.RuneCountInString(sentence) >= maxRunes/2 {
+			return sentence
+		}
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool { return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != ')') }) + "…"
+}
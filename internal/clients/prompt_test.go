@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"context"
+	"hazel_ai/internal/templates"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEnforceLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWords int
+		language string
+		want     string
+	}{
+		{"short enough", "Happy birthday, Ada!", 10, "en", "Happy birthday, Ada!"},
+		{"ends at a sentence", "Happy birthday, Ada! Have a lovely day with all your friends and family.", 6, "en", "Happy birthday, Ada!"},
+		{"cut mid-sentence", "Wishing you a wonderful day full of joy, cake and friends.", 6, "en", "Wishing you a wonderful day full…"},
+		{"japanese short enough", "お誕生日おめでとう！", 10, "ja", "お誕生日おめでとう！"},
+		{"japanese ends at a sentence", "お誕生日おめでとうございます！素敵な一年になりますように。", 10, "ja", "お誕生日おめでとうございます！"},
+		{"chinese cut mid-sentence", "祝你生日快乐，愿你的每一天都充满阳光和欢笑，心想事成，万事如意。", 5, "zh", "祝你生日快乐，愿你的…"},
+		{"japanese without a language is one word", "お誕生日おめでとうございます！素敵な一年になりますように。", 10, "", "お誕生日おめでとうございます！素敵な一年になりますように。"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EnforceLength(tt.text, tt.maxWords, tt.language); got != tt.want {
+				t.Errorf("EnforceLength = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateWishesEnforceLength(t *testing.T) {
+	dir := t.TempDir()
+	japanese := "{{/*\nkind: wish\nlanguage: ja\n*/ -}}\nお誕生日おめでとうございます！素敵な一年になりますように。たくさんの笑顔と幸せに包まれた毎日を過ごしてください。"
+	if err := os.WriteFile(filepath.Join(dir, "wish-ja.tmpl"), []byte(japanese), 0644); err != nil {
+		t.Fatal(err)
+	}
+	library, err := templates.New(dir, templates.SelectRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := TemplateWishes{Library: library}
+
+	wish, err := g.GenerateWish(context.Background(), WishRequest{Name: "Ada", Language: "en", MaxWords: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Fields(wish.Text)); n > 10 {
+		t.Errorf("English template wish has %d words, want at most 10: %q", n, wish.Text)
+	}
+
+	wish, err = g.GenerateWish(context.Background(), WishRequest{Language: "ja", MaxWords: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(wish.Text); n > 10*charsPerWord+1 {
+		t.Errorf("Japanese template wish has %d characters, want at most %d: %q", n, 10*charsPerWord, wish.Text)
+	}
+	if wish.Template != "wish-ja.tmpl" {
+		t.Errorf("template = %s, want wish-ja.tmpl", wish.Template)
+	}
+}
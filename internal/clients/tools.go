@@ -0,0 +1,109 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// ErrNoToolCall is returned when Gemini answers without choosing a tool
+var ErrNoToolCall = errors.New("gemini did not call a tool")
+
+// ToolParam is one parameter of a Tool
+type ToolParam struct {
+	Name        string
+	Description string
+	// Type is "string" or "integer"
+	Type     string
+	Required bool
+}
+
+// Tool declares a function Gemini may ask Hazel to call
+type Tool struct {
+	Name        string
+	Description string
+	Params      []ToolParam
+}
+
+// ToolCall is the function Gemini chose and the arguments it filled in
+type ToolCall struct {
+	Name string
+	Args map[string]any
+}
+
+// String returns the argument as a string, or "" when it is missing
+func (tc ToolCall) String(name string) string {
+	switch v := tc.Args[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	}
+	return ""
+}
+
+// Int returns the argument as an int, or 0 when it is missing
+func (tc ToolCall) Int(name string) int {
+	switch v := tc.Args[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// ChooseTool sends the message to Gemini with the tools declared and returns
+// the call it asks for. instructions are sent as the system prompt.
+func (g *GeminiClient) ChooseTool(instructions, message string, tools []Tool) (ToolCall, error) {
+	// Use timeout to prevent hanging
+	ctx, cancel := context.WithTimeout(context.Background(), g.opts.timeout())
+	defer cancel()
+
+	declarations := make([]*genai.FunctionDeclaration, len(tools))
+	for i, tool := range tools {
+		declarations[i] = toolDeclaration(tool)
+	}
+
+	result, err := g.client.Models.GenerateContent(
+		ctx,
+		g.opts.Model,
+		genai.Text(message),
+		&genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(instructions, genai.RoleUser),
+			Tools:             []*genai.Tool{{FunctionDeclarations: declarations}},
+		},
+	)
+	if err != nil {
+		return ToolCall{}, fmt.Errorf("failed to choose a tool: %w", err)
+	}
+
+	calls := result.FunctionCalls()
+	if len(calls) == 0 {
+		return ToolCall{}, ErrNoToolCall
+	}
+	return ToolCall{Name: calls[0].Name, Args: calls[0].Args}, nil
+}
+
+func toolDeclaration(tool Tool) *genai.FunctionDeclaration {
+	declaration := &genai.FunctionDeclaration{Name: tool.Name, Description: tool.Description}
+	if len(tool.Params) == 0 {
+		return declaration
+	}
+
+	schema := &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{}}
+	for _, p := range tool.Params {
+		paramType := genai.TypeString
+		if p.Type == "integer" {
+			paramType = genai.TypeInteger
+		}
+		schema.Properties[p.Name] = &genai.Schema{Type: paramType, Description: p.Description}
+		if p.Required {
+			schema.Required = append(schema.Required, p.Name)
+		}
+	}
+	declaration.Parameters = schema
+	return declaration
+}
@@ -0,0 +1,347 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/templates"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Wish providers that can be listed in HAZEL_WISH_PROVIDERS
+const (
+	ProviderGemini   = "gemini"
+	ProviderOpenAI   = "openai"
+	ProviderTemplate = "template"
+)
+
+// defaultTimeout bounds a single wish request when Options.Timeout is unset
+const defaultTimeout = 10 * time.Second
+
+// Options configures a wish provider. Zero values use the provider's defaults.
+type Options struct {
+	Model   string
+	BaseURL string
+	APIKey  string
+	// Temperature is the sampling temperature; 0 uses the provider's default
+	Temperature float64
+	MaxTokens   int
+	Timeout     time.Duration
+}
+
+func (o Options) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return defaultTimeout
+}
+
+// WishRequest describes the wish to write. Only Name and Age are needed;
+// the rest tailor it and use defaults when empty.
+type WishRequest struct {
+	// Name is who the wish is for, or "" for a generic wish
+	Name string
+	// Age is the age being turned, or 0 when it isn't known or is private
+	Age int
+	// Tone is one of Tones; "" means ToneWarm
+	Tone string
+	// Language is an ISO 639-1 code such as "es"; "" means English
+	Language string
+	// Relationship is who the person is to the sender, e.g. "manager"
+	Relationship string
+	// MaxWords caps the wish's length; 0 means DefaultMaxWords
+	MaxWords  int
+	Interests []string
+	Notes     string
+	// Feedback is what to change from earlier drafts, e.g. "shorter"
+	Feedback string
+	// Avoid are earlier drafts the new wish mustn't repeat
+	Avoid []string
+	// AvoidTemplates names templates the template provider should skip
+	AvoidTemplates []string
+}
+
+// Validate checks the request's options and fills in their defaults
+func (r *WishRequest) Validate() error {
+	r.Tone = strings.ToLower(strings.TrimSpace(r.Tone))
+	if r.Tone == "" {
+		r.Tone = ToneWarm
+	}
+	if !slices.Contains(Tones, r.Tone) {
+		return fmt.Errorf("unknown tone %q: use one of %s", r.Tone, strings.Join(Tones, ", "))
+	}
+
+	if r.MaxWords == 0 {
+		r.MaxWords = DefaultMaxWords
+	}
+	if r.MaxWords < MinWishWords || r.MaxWords > MaxWishWords {
+		return fmt.Errorf("max_words must be between %d and %d", MinWishWords, MaxWishWords)
+	}
+
+	if r.Language != "" {
+		code, ok := LanguageCode(r.Language)
+		if !ok {
+			return fmt.Errorf("unsupported language %q", r.Language)
+		}
+		r.Language = code
+	}
+
+	r.Relationship = strings.TrimSpace(r.Relationship)
+	if len(r.Relationship) > 50 {
+		return errors.New("relationship is limited to 50 characters")
+	}
+
+	r.Feedback = strings.TrimSpace(r.Feedback)
+	if len(r.Feedback) > 200 {
+		return errors.New("feedback is limited to 200 characters")
+	}
+	return nil
+}
+
+// Wish is a generated birthday wish
+type Wish struct {
+	Text string
+	// Source is the provider that wrote the wish, e.g. "gemini"
+	Source string
+	// Model is the language model that wrote it, if any
+	Model string
+	// Template is the name of the template it was rendered from, if any
+	Template string
+}
+
+// WishGenerator writes birthday wishes
+type WishGenerator interface {
+	// Name identifies the provider
+	Name() string
+	GenerateWish(ctx context.Context, req WishRequest) (Wish, error)
+}
+
+// MultiWishGenerator is a WishGenerator that can write several different
+// wishes in one request
+type MultiWishGenerator interface {
+	WishGenerator
+	GenerateWishes(ctx context.Context, req WishRequest, n int) ([]Wish, error)
+}
+
+// StreamingWishGenerator is a WishGenerator that can hand over the wish
+// while it is still being written
+type StreamingWishGenerator interface {
+	WishGenerator
+	// StreamWish passes each new piece of the wish to onChunk and returns the
+	// finished wish, which may differ from the pieces once cut to length
+	StreamWish(ctx context.Context, req WishRequest, onChunk func(string)) (Wish, error)
+}
+
+// Candidates asks g for n different wishes, in one request when g is a
+// MultiWishGenerator and one at a time otherwise. Wishes repeating each other
+// or req.Avoid are dropped, so fewer than n may come back.
+func Candidates(ctx context.Context, g WishGenerator, req WishRequest, n int) ([]Wish, error) {
+	var wishes []Wish
+	if multi, ok := g.(MultiWishGenerator); ok {
+		var err error
+		if wishes, err = multi.GenerateWishes(ctx, req, n); err != nil {
+			return nil, err
+		}
+	} else {
+		for range n {
+			wish, err := g.GenerateWish(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			wishes = append(wishes, wish)
+		}
+	}
+
+	wishes = distinct(wishes, req.Avoid)
+	if len(wishes) == 0 {
+		return nil, fmt.Errorf("%s only repeated earlier wishes", g.Name())
+	}
+	return wishes[:min(n, len(wishes))], nil
+}
+
+// CandidatesOrFallback is Candidates with template wishes making up for a
+// failed or short answer, so there is always at least one wish
+func CandidatesOrFallback(ctx context.Context, g WishGenerator, req WishRequest, n int) []Wish {
+	var wishes []Wish
+	if g != nil {
+		var err error
+		if wishes, err = Candidates(ctx, g, req, n); err != nil {
+			log.Printf("Error generating %d birthday wishes for %q: %v", n, req.Name, err)
+		}
+	}
+	if len(wishes) >= n {
+		return wishes
+	}
+
+	fallback := req
+	for _, wish := range wishes {
+		fallback.Avoid = append(slices.Clip(fallback.Avoid), wish.Text)
+	}
+	extra, _ := TemplateWishes{}.GenerateWishes(ctx, fallback, n-len(wishes))
+	for _, wish := range distinct(extra, fallback.Avoid) {
+		wish.Source = "fallback"
+		wishes = append(wishes, wish)
+	}
+	if len(wishes) == 0 {
+		// Every template has been used already; repeating one beats sending nothing
+		wishes = append(wishes, WishOrFallback(ctx, nil, WishRequest{Name: req.Name, Age: req.Age, Tone: req.Tone, Language: req.Language}))
+	}
+	return wishes
+}
+
+// distinct drops empty wishes and ones repeating another or any of avoid,
+// ignoring case and spacing
+func distinct(wishes []Wish, avoid []string) []Wish {
+	key := func(text string) string { return strings.ToLower(strings.Join(strings.Fields(text), " ")) }
+
+	seen := make(map[string]bool, len(wishes)+len(avoid))
+	for _, text := range avoid {
+		seen[key(text)] = true
+	}
+	var kept []Wish
+	for _, wish := range wishes {
+		k := key(wish.Text)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		kept = append(kept, wish)
+	}
+	return kept
+}
+
+// Chain tries each generator in turn until one succeeds
+type Chain []WishGenerator
+
+func (c Chain) Name() string { return "chain" }
+
+func (c Chain) GenerateWish(ctx context.Context, req WishRequest) (Wish, error) {
+	if len(c) == 0 {
+		return Wish{}, errors.New("no wish providers configured")
+	}
+
+	var errs []error
+	for _, g := range c {
+		wish, err := g.GenerateWish(ctx, req)
+		if err == nil {
+			return wish, nil
+		}
+		log.Printf("Wish provider %s failed, trying the next one: %v", g.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", g.Name(), err))
+	}
+	return Wish{}, errors.Join(errs...)
+}
+
+// StreamWish tries each generator in turn until one succeeds, streaming from
+// those that can
+func (c Chain) StreamWish(ctx context.Context, req WishRequest, onChunk func(string)) (Wish, error) {
+	if len(c) == 0 {
+		return Wish{}, errors.New("no wish providers configured")
+	}
+
+	var errs []error
+	for _, g := range c {
+		wish, err := generate(ctx, g, req, onChunk)
+		if err == nil {
+			return wish, nil
+		}
+		log.Printf("Wish provider %s failed, trying the next one: %v", g.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", g.Name(), err))
+	}
+	return Wish{}, errors.Join(errs...)
+}
+
+// GenerateWishes asks each generator in turn for n wishes until one succeeds
+func (c Chain) GenerateWishes(ctx context.Context, req WishRequest, n int) ([]Wish, error) {
+	if len(c) == 0 {
+		return nil, errors.New("no wish providers configured")
+	}
+
+	var errs []error
+	for _, g := range c {
+		wishes, err := Candidates(ctx, g, req, n)
+		if err == nil {
+			return wishes, nil
+		}
+		log.Printf("Wish provider %s failed, trying the next one: %v", g.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", g.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// WishOrFallback asks g for a wish, using the built-in template when g is
+// nil, fails or repeats one of req.Avoid, so there is always something to send
+func WishOrFallback(ctx context.Context, g WishGenerator, req WishRequest) Wish {
+	return StreamWishOrFallback(ctx, g, req, nil)
+}
+
+// StreamWishOrFallback is WishOrFallback that streams the wish to onChunk
+// when g can. Pieces of a wish that fails halfway have already been passed
+// on, so the returned wish replaces whatever onChunk was given.
+func StreamWishOrFallback(ctx context.Context, g WishGenerator, req WishRequest, onChunk func(string)) Wish {
+	if g != nil {
+		wish, err := generate(ctx, g, req, onChunk)
+		if err == nil && len(distinct([]Wish{wish}, req.Avoid)) == 0 {
+			err = fmt.Errorf("%s repeated an earlier wish", g.Name())
+		}
+		if err == nil {
+			return wish
+		}
+		log.Printf("Error generating birthday wish for %q: %v", req.Name, err)
+	}
+
+	wish, _ := TemplateWishes{}.GenerateWish(ctx, req)
+	wish.Source = "fallback"
+	return wish
+}
+
+// generate asks g for a wish, streaming it to onChunk when both are set
+func generate(ctx context.Context, g WishGenerator, req WishRequest, onChunk func(string)) (Wish, error) {
+	if streaming, ok := g.(StreamingWishGenerator); ok && onChunk != nil {
+		return streaming.StreamWish(ctx, req, onChunk)
+	}
+	return g.GenerateWish(ctx, req)
+}
+
+// TemplateWishes writes wishes from the template library without calling
+// any service
+type TemplateWishes struct {
+	// Library is the template library to use, or nil for templates.Default()
+	Library *templates.Library
+}
+
+func (TemplateWishes) Name() string { return ProviderTemplate }
+
+func (t TemplateWishes) GenerateWish(ctx context.Context, req WishRequest) (Wish, error) {
+	library := t.Library
+	if library == nil {
+		library = templates.Default()
+	}
+	// Templates have no poetic tone; Render falls back to another one
+	query := templates.Query{Kind: templates.KindWish, Tone: req.Tone, Language: req.Language, Age: req.Age, Exclude: req.AvoidTemplates}
+	text, name := library.RenderTemplate(query, templates.Data{Name: req.Name, Age: req.Age})
+	// The template may be in another language than the one asked for
+	language := templates.DefaultLanguage
+	if t, ok := library.Lookup(name); ok {
+		language = t.Language
+	}
+	return Wish{Text: EnforceLength(text, req.MaxWords, language), Source: ProviderTemplate, Template: name}, nil
+}
+
+// GenerateWishes renders up to n different templates, skipping ones whose
+// text is in req.Avoid. It may return fewer when the templates run out.
+func (t TemplateWishes) GenerateWishes(ctx context.Context, req WishRequest, n int) ([]Wish, error) {
+	var wishes []Wish
+	// Selection can repeat a template, so allow a few extra tries
+	for range n * 4 {
+		wish, _ := t.GenerateWish(ctx, req)
+		wishes = distinct(append(wishes, wish), req.Avoid)
+		if len(wishes) == n {
+			break
+		}
+	}
+	return wishes, nil
+}
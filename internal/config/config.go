@@ -0,0 +1,220 @@
+package config
+
+import (
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/store"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings read from the environment
+type Config struct {
+	Port         string
+	StoreBackend string
+	StoreFile    string
+	StoreBackups int
+	StoreRecover bool
+	LedgerFile   string
+	// WishHistoryFile records the wishes generated for each birthday
+	WishHistoryFile string
+	TelexWebhookURL string
+	// PushConfigFile keeps the A2A push notification configs clients register
+	PushConfigFile string
+	// PushAttempts and PushBackoff control retries of push notifications
+	PushAttempts int
+	PushBackoff  time.Duration
+	// PushAllowPrivate lets push URLs point at loopback, private and
+	// link-local addresses
+	PushAllowPrivate bool
+
+	// APIKeys maps each REST API key to the tenant whose birthday book it can
+	// reach. When empty the REST API is open and uses the default tenant.
+	APIKeys map[string]string
+
+	// LeapDayPolicy is "feb28" or "mar1": when Feb 29 birthdays are
+	// celebrated in non-leap years
+	LeapDayPolicy string
+
+	// DateOrder is "mdy" or "dmy": how numeric dates such as 04/05 are read
+	DateOrder string
+
+	// WishProviders are tried in order until one writes a wish:
+	// "gemini", "openai" and "template"
+	WishProviders []string
+	// Gemini and OpenAI configure those wish providers
+	Gemini clients.Options
+	OpenAI clients.Options
+
+	// TemplateDir holds *.tmpl wish templates that add to or replace the
+	// built-in ones; TemplateSelection is "random" or "round-robin"
+	TemplateDir       string
+	TemplateSelection string
+
+	// ToolCalling routes chat messages through Gemini function calling,
+	// falling back to the intent router
+	ToolCalling bool
+
+	// ConversationTTL is how long Hazel waits for the answer to a follow-up question
+	ConversationTTL time.Duration
+	// WishSessionTTL is how long candidate wishes are kept for regenerating
+	WishSessionTTL time.Duration
+	// TaskTTL is how long A2A tasks can be fetched after their last change
+	TaskTTL time.Duration
+
+	// CatchUpDays is how far back the startup catch-up looks for missed birthdays
+	CatchUpDays int
+
+	SchedulerEnabled bool
+	// Cron expressions for the built-in jobs. An empty value disables the job.
+	TodayCron    string
+	TomorrowCron string
+	DigestCron   string
+}
+
+// Load reads the configuration from environment variables, applying defaults
+func Load() Config {
+	backend := getEnv("HAZEL_STORE_BACKEND", store.BackendJSON)
+	defaultFile := "birthdays.json"
+	if backend == store.BackendSQLite {
+		defaultFile = "birthdays.db"
+	}
+	storeFile := getEnv("HAZEL_STORE_FILE", defaultFile)
+
+	return Config{
+		Port:            getEnv("PORT", "3000"),
+		StoreBackend:    backend,
+		StoreFile:       storeFile,
+		StoreBackups:    getInt("HAZEL_STORE_BACKUPS", 3),
+		StoreRecover:    getBool("HAZEL_STORE_RECOVER", false),
+		LedgerFile:      getEnv("HAZEL_LEDGER_FILE", store.LedgerFileFor(storeFile)),
+		WishHistoryFile: getEnv("HAZEL_WISH_HISTORY_FILE", store.WishHistoryFileFor(storeFile)),
+		TelexWebhookURL: os.Getenv("TELEX_WEBHOOK_URL"),
+		PushConfigFile:  getEnv("HAZEL_PUSH_CONFIG_FILE", store.PushConfigFileFor(storeFile)),
+		PushAttempts:    getInt("HAZEL_PUSH_ATTEMPTS", 3),
+		PushBackoff:     getDuration("HAZEL_PUSH_BACKOFF", time.Second),
+		APIKeys:         getPairs("HAZEL_API_KEYS"),
+
+		PushAllowPrivate: getBool("HAZEL_PUSH_ALLOW_PRIVATE", false),
+
+		LeapDayPolicy: getEnv("HAZEL_LEAP_DAY_POLICY", "feb28"),
+		DateOrder:     getEnv("HAZEL_DATE_ORDER", "mdy"),
+
+		WishProviders: getList("HAZEL_WISH_PROVIDERS", []string{clients.ProviderGemini, clients.ProviderTemplate}),
+		Gemini:        providerOptions("HAZEL_GEMINI", os.Getenv("GEMINI_API_KEY"), ""),
+		OpenAI:        providerOptions("HAZEL_OPENAI", os.Getenv("OPENAI_API_KEY"), clients.DefaultOpenAIBaseURL),
+
+		TemplateDir:       getEnv("HAZEL_TEMPLATE_DIR", ""),
+		TemplateSelection: getEnv("HAZEL_TEMPLATE_SELECTION", "random"),
+
+		ToolCalling:     getBool("HAZEL_TOOL_CALLING", false),
+		ConversationTTL: getDuration("HAZEL_CONVERSATION_TTL", 10*time.Minute),
+		WishSessionTTL:  getDuration("HAZEL_WISH_SESSION_TTL", time.Hour),
+		TaskTTL:         getDuration("HAZEL_TASK_TTL", 24*time.Hour),
+		CatchUpDays:     getInt("HAZEL_CATCHUP_DAYS", 3),
+
+		SchedulerEnabled: getBool("HAZEL_SCHEDULER_ENABLED", true),
+		TodayCron:        getEnv("HAZEL_CRON_TODAY", "0 8 * * *"),
+		TomorrowCron:     getEnv("HAZEL_CRON_TOMORROW", "0 18 * * *"),
+		DigestCron:       getEnv("HAZEL_CRON_DIGEST", "0 9 * * 1"),
+	}
+}
+
+// getEnv returns the variable's value, or the fallback when it is unset.
+// A variable that is set but empty is returned as-is so it can disable features.
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return fallback
+}
+
+func getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func getFloat(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+// getList parses a comma-separated list such as "gemini,template"
+func getList(key string, fallback []string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	if len(list) == 0 {
+		return fallback
+	}
+	return list
+}
+
+// providerOptions reads a wish provider's settings from the variables
+// starting with prefix, e.g. HAZEL_OPENAI_MODEL
+func providerOptions(prefix, apiKey, baseURL string) clients.Options {
+	return clients.Options{
+		Model:       getEnv(prefix+"_MODEL", ""),
+		BaseURL:     getEnv(prefix+"_BASE_URL", baseURL),
+		APIKey:      getEnv(prefix+"_API_KEY", apiKey),
+		Temperature: getFloat(prefix+"_TEMPERATURE", 0),
+		MaxTokens:   getInt(prefix+"_MAX_TOKENS", 0),
+		Timeout:     getDuration(prefix+"_TIMEOUT", 10*time.Second),
+	}
+}
+
+// getPairs parses a comma-separated list of key=value pairs such as
+// "k1=team-a,k2=team-b". Malformed entries are skipped.
+func getPairs(key string) map[string]string {
+	pairs := map[string]string{}
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return pairs
+}
@@ -0,0 +1,137 @@
+// Package conversation remembers what Hazel is waiting for in each chat so a
+// follow-up answer ("2005-01-01", "Alice") can finish the request that
+// prompted it.
+package conversation
+
+import (
+	"hazel_ai/internal/store"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Intents that can be left pending while Hazel waits for an answer
+const (
+	IntentRemember = "remember"
+	IntentForget   = "forget"
+	IntentChange   = "change"
+	IntentWhen     = "when"
+	IntentAge      = "age"
+)
+
+// Slots an intent may need filled
+const (
+	SlotName = "name"
+	SlotSelf = "self"
+	SlotDate = "date"
+	SlotYear = "year"
+	SlotID   = "id"
+	// SlotCandidates holds comma-separated IDs while Hazel asks which one was meant
+	SlotCandidates = "candidates"
+	// SlotConfirm is awaited before a destructive change
+	SlotConfirm = "confirm"
+	// SlotUndo is awaited after a destructive change, in case the user says "undo"
+	SlotUndo = "undo"
+)
+
+// State is the pending intent of one conversation
+type State struct {
+	Intent string
+	Slots  map[string]string
+	// Awaiting is the slot the last reply asked the user for
+	Awaiting string
+	// Undo is the birthday as it was before the last change or deletion
+	Undo      *store.Birthday
+	ExpiresAt time.Time
+}
+
+// Has reports whether the slot is filled
+func (s State) Has(slot string) bool {
+	return s.Slots[slot] != ""
+}
+
+// clone copies the state's slots and undo entry so no two copies share them
+func (s State) clone() State {
+	s.Slots = maps.Clone(s.Slots)
+	if s.Undo != nil {
+		undo := *s.Undo
+		s.Undo = &undo
+	}
+	return s
+}
+
+// Store keeps conversation state in memory. States expire after the TTL so
+// an unanswered question doesn't hijack a message sent hours later.
+type Store struct {
+	mu     sync.Mutex
+	states map[string]State
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewStore creates a store whose states expire after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		states: make(map[string]State),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// SetClock replaces the store's time source
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Get returns the pending state for the conversation, if any and not
+// expired. The state is a copy, so the caller may change its slots while
+// other messages in the conversation are handled.
+func (s *Store) Get(key string) (State, bool) {
+	if key == "" {
+		return State{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return State{}, false
+	}
+	if !s.now().Before(state.ExpiresAt) {
+		delete(s.states, key)
+		return State{}, false
+	}
+	return state.clone(), true
+}
+
+// Put saves a copy of the conversation's state and restarts its TTL.
+// Conversations without a key can't be followed up, so nothing is saved for
+// them.
+func (s *Store) Put(key string, state State) {
+	if key == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, st := range s.states {
+		if !now.Before(st.ExpiresAt) {
+			delete(s.states, k)
+		}
+	}
+
+	state.ExpiresAt = now.Add(s.ttl)
+	s.states[key] = state.clone()
+}
+
+// Clear forgets the conversation's pending state
+func (s *Store) Clear(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+}
@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"fmt"
+	"hazel_ai/internal/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreCopiesState(t *testing.T) {
+	s := NewStore(time.Hour)
+	state := State{Intent: IntentRemember, Slots: map[string]string{SlotName: "Alice"}, Undo: &store.Birthday{Name: "Alice"}}
+	s.Put("k", state)
+
+	// Changing the state after Put doesn't reach the store
+	state.Slots[SlotName] = "Bob"
+	state.Undo.Name = "Bob"
+	got, ok := s.Get("k")
+	if !ok || got.Slots[SlotName] != "Alice" || got.Undo.Name != "Alice" {
+		t.Fatalf("Get = %+v, %v; want Alice as saved", got, ok)
+	}
+
+	// Nor does changing what Get returned
+	got.Slots[SlotDate] = "03-03"
+	got.Undo.Name = "Carol"
+	again, _ := s.Get("k")
+	if again.Has(SlotDate) || again.Undo.Name != "Alice" {
+		t.Errorf("Get = %+v, want the saved state untouched", again)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	now := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	s := NewStore(time.Minute)
+	s.SetClock(func() time.Time { return now })
+
+	s.Put("k", State{Intent: IntentRemember, Slots: map[string]string{}})
+	if _, ok := s.Get("k"); !ok {
+		t.Fatal("state missing before the TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := s.Get("k"); ok {
+		t.Error("state still there after the TTL")
+	}
+	if _, ok := s.Get(""); ok {
+		t.Error("Get with no key found a state")
+	}
+}
+
+// TestStoreConcurrentAnswers is meant for go test -race: replies handled at
+// the same time in one conversation each change their own copy of its state
+func TestStoreConcurrentAnswers(t *testing.T) {
+	s := NewStore(time.Hour)
+	s.Put("k", State{Intent: IntentRemember, Slots: map[string]string{SlotName: "Alice"}})
+
+	var wg, fetched sync.WaitGroup
+	fetched.Add(8)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, ok := s.Get("k")
+			// Every reply holds the state before any of them changes it
+			fetched.Done()
+			fetched.Wait()
+			if !ok {
+				return
+			}
+			state.Slots[SlotDate] = fmt.Sprintf("03-%02d", i+1)
+			state.Awaiting = SlotYear
+			s.Put("k", state)
+		}()
+	}
+	wg.Wait()
+
+	if state, ok := s.Get("k"); !ok || state.Slots[SlotName] != "Alice" || !state.Has(SlotDate) {
+		t.Errorf("Get = %+v, %v; want Alice with one of the dates", state, ok)
+	}
+}
@@ -0,0 +1,257 @@
+// Package dateparse reads birthdays the way people type them: ISO dates,
+// numeric dates in either day/month order, month names with ordinals and
+// dates without a year.
+package dateparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Order decides how ambiguous numeric dates such as 04/05 are read
+type Order int
+
+const (
+	// MonthFirst reads 04/05 as April 5 (the default)
+	MonthFirst Order = iota
+	// DayFirst reads 04/05 as 4 May
+	DayFirst
+)
+
+// ParseOrder parses "mdy" or "dmy"
+func ParseOrder(s string) (Order, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "mdy", "month-first":
+		return MonthFirst, nil
+	case "dmy", "day-first":
+		return DayFirst, nil
+	default:
+		return MonthFirst, fmt.Errorf("unknown date order %q (expected mdy or dmy)", s)
+	}
+}
+
+func (o Order) String() string {
+	if o == DayFirst {
+		return "dmy"
+	}
+	return "mdy"
+}
+
+// Date is a calendar date whose year may be unknown (0)
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// HasYear reports whether the year was given
+func (d Date) HasYear() bool {
+	return d.Year > 0
+}
+
+// String formats the date as YYYY-MM-DD, or MM-DD without a year
+func (d Date) String() string {
+	if d.HasYear() {
+		return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+	}
+	return fmt.Sprintf("%02d-%02d", d.Month, d.Day)
+}
+
+// ErrNoDate is returned when the text doesn't contain anything that looks like a date
+var ErrNoDate = errors.New("no date found")
+
+// InvalidDateError is returned for text that looks like a date but names a
+// day that doesn't exist, such as 02-30 or 13/01/1990
+type InvalidDateError struct {
+	Text   string
+	Reason string
+}
+
+func (e *InvalidDateError) Error() string {
+	return fmt.Sprintf("%q is not a valid date: %s", e.Text, e.Reason)
+}
+
+// Parser reads dates. The zero value reads numeric dates month first.
+type Parser struct {
+	Order Order
+	// Now is used to expand two-digit years; defaults to time.Now
+	Now func() time.Time
+}
+
+const (
+	months   = `january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec`
+	ordinal  = `(\d{1,2})(?:st|nd|rd|th)?`
+	fullYear = `(\d{4})`
+)
+
+// pattern is one way of writing a date; read turns its submatches into a Date
+type pattern struct {
+	re   *regexp.Regexp
+	read func(p Parser, m []string) (Date, error)
+}
+
+var patterns = []pattern{
+	// 1990-12-25, 1990/12/25
+	{regexp.MustCompile(`\b` + fullYear + `[-/](\d{1,2})[-/](\d{1,2})\b`), func(p Parser, m []string) (Date, error) {
+		return numbers(m[1], m[2], m[3])
+	}},
+	// 25.12.1990, 25.12.90 - dots are always day first. The year is required
+	// so that numbers like 2.5 aren't mistaken for dates.
+	{regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4}|\d{2})\b`), func(p Parser, m []string) (Date, error) {
+		return numbers(p.year(m[3]), m[2], m[1])
+	}},
+	// 12/25/1990, 25/12/1990, 12-25-1990, 12/25 - in the configured order
+	{regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{4}|\d{2}))?\b|\b(\d{1,2})-(\d{1,2})-(\d{4})\b`), func(p Parser, m []string) (Date, error) {
+		a, b, year := m[1], m[2], m[3]
+		if a == "" {
+			a, b, year = m[4], m[5], m[6]
+		}
+		return p.ordered(a, b, p.year(year))
+	}},
+	// 12-25 - year-less ISO style is always month first
+	{regexp.MustCompile(`\b(\d{1,2})-(\d{1,2})\b`), func(p Parser, m []string) (Date, error) {
+		return numbers("", m[1], m[2])
+	}},
+	// January 1st 2005, Jan 1, 2005, March the 3rd
+	{regexp.MustCompile(`(?i)\b(` + months + `)\.?\s+(?:the\s+)?` + ordinal + `\b(?:,?\s+` + fullYear + `\b)?`), func(p Parser, m []string) (Date, error) {
+		return named(m[3], m[1], m[2])
+	}},
+	// 1 January 2005, 1st Jan, the 3rd of March
+	{regexp.MustCompile(`(?i)\b(?:the\s+)?` + ordinal + `\s+(?:of\s+)?(` + months + `)\b\.?(?:,?\s+` + fullYear + `\b)?`), func(p Parser, m []string) (Date, error) {
+		return named(m[3], m[2], m[1])
+	}},
+}
+
+// Parse reads text that should be nothing but a date
+func (p Parser) Parse(text string) (Date, error) {
+	text = strings.TrimSpace(text)
+	d, match, err := p.Find(text)
+	if err != nil {
+		return Date{}, err
+	}
+	if match != text {
+		return Date{}, &InvalidDateError{Text: text, Reason: "unexpected text around the date"}
+	}
+	return d, nil
+}
+
+// Find returns the first date in free text along with the text it was read
+// from. It returns ErrNoDate when there is none, and an *InvalidDateError
+// when the first date-like text names an impossible day.
+func (p Parser) Find(text string) (Date, string, error) {
+	var best []int
+	var bestPattern pattern
+	for _, pat := range patterns {
+		loc := pat.re.FindStringSubmatchIndex(text)
+		if loc == nil {
+			continue
+		}
+		// Prefer the earliest match, then the longest
+		if best == nil || loc[0] < best[0] || (loc[0] == best[0] && loc[1] > best[1]) {
+			best, bestPattern = loc, pat
+		}
+	}
+	if best == nil {
+		return Date{}, "", ErrNoDate
+	}
+
+	match := text[best[0]:best[1]]
+	groups := make([]string, len(best)/2)
+	for i := range groups {
+		if best[2*i] >= 0 {
+			groups[i] = text[best[2*i]:best[2*i+1]]
+		}
+	}
+
+	d, err := bestPattern.read(p, groups)
+	if err != nil {
+		var invalid *InvalidDateError
+		if errors.As(err, &invalid) {
+			invalid.Text = match
+		}
+		return Date{}, match, err
+	}
+	return d, match, nil
+}
+
+// ordered reads two numbers in the parser's order. When that order gives an
+// impossible month but the other order works (25/12 read month first), the
+// numbers are swapped rather than rejected.
+func (p Parser) ordered(a, b, year string) (Date, error) {
+	month, day := a, b
+	if p.Order == DayFirst {
+		month, day = b, a
+	}
+	if m, _ := strconv.Atoi(month); m > 12 {
+		if d, _ := strconv.Atoi(day); d <= 12 {
+			month, day = day, month
+		}
+	}
+	return numbers(year, month, day)
+}
+
+// year expands a two-digit year to the most recent matching year
+func (p Parser) year(s string) string {
+	if len(s) != 2 {
+		return s
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	yy, _ := strconv.Atoi(s)
+	current := now().Year()
+	year := current - current%100 + yy
+	if year > current {
+		year -= 100
+	}
+	return strconv.Itoa(year)
+}
+
+func named(year, month, day string) (Date, error) {
+	month = strings.ToLower(month)
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), month[:3]) {
+			return numbers(year, strconv.Itoa(int(m)), day)
+		}
+	}
+	return Date{}, &InvalidDateError{Reason: fmt.Sprintf("unknown month %q", month)}
+}
+
+// numbers validates a date given as decimal strings; year may be empty
+func numbers(year, month, day string) (Date, error) {
+	var d Date
+	var err error
+	if year != "" {
+		if d.Year, err = strconv.Atoi(year); err != nil || d.Year < 1 {
+			return Date{}, &InvalidDateError{Reason: fmt.Sprintf("year %s is out of range", year)}
+		}
+	}
+	if d.Month, err = strconv.Atoi(month); err != nil || d.Month < 1 || d.Month > 12 {
+		return Date{}, &InvalidDateError{Reason: fmt.Sprintf("month %s is out of range", month)}
+	}
+	if d.Day, err = strconv.Atoi(day); err != nil || d.Day < 1 {
+		return Date{}, &InvalidDateError{Reason: fmt.Sprintf("day %s is out of range", day)}
+	}
+
+	if max := daysIn(d.Year, time.Month(d.Month)); d.Day > max {
+		reason := fmt.Sprintf("%s has only %d days", time.Month(d.Month), max)
+		if d.Month == int(time.February) && d.HasYear() && max == 28 {
+			reason = fmt.Sprintf("%d is not a leap year", d.Year)
+		}
+		return Date{}, &InvalidDateError{Reason: reason}
+	}
+	return d, nil
+}
+
+// daysIn returns the length of the month; an unknown year allows February 29
+func daysIn(year int, month time.Month) int {
+	if year == 0 {
+		year = 2000
+	}
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
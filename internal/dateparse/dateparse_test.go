@@ -0,0 +1,149 @@
+package dateparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func fixedNow() time.Time {
+	return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	mdy := Parser{Order: MonthFirst, Now: fixedNow}
+	dmy := Parser{Order: DayFirst, Now: fixedNow}
+
+	tests := []struct {
+		name   string
+		parser Parser
+		text   string
+		want   string
+	}{
+		{"iso", mdy, "1990-12-25", "1990-12-25"},
+		{"iso with slashes", dmy, "1990/12/25", "1990-12-25"},
+		{"year-less iso", dmy, "04-05", "04-05"},
+		{"mdy slashes", mdy, "04/05/1990", "1990-04-05"},
+		{"dmy slashes", dmy, "04/05/1990", "1990-05-04"},
+		{"mdy dashes", mdy, "04-05-1990", "1990-04-05"},
+		{"dmy dashes", dmy, "04-05-1990", "1990-05-04"},
+		{"mdy without year", mdy, "4/5", "04-05"},
+		{"dmy without year", dmy, "4/5", "05-04"},
+		{"25/12 swapped when month first", mdy, "25/12", "12-25"},
+		{"12/25 swapped when day first", dmy, "12/25/1990", "1990-12-25"},
+		{"two-digit year in the past", mdy, "12/25/90", "1990-12-25"},
+		{"two-digit year this century", mdy, "12/25/05", "2005-12-25"},
+		{"two-digit year equal to this year", mdy, "01/02/25", "2025-01-02"},
+		{"two-digit year just ahead", mdy, "01/02/26", "1926-01-02"},
+		{"dotted is day first", mdy, "25.12.1990", "1990-12-25"},
+		{"dotted two-digit year", mdy, "3.4.90", "1990-04-03"},
+		{"month name", mdy, "January 1st 2005", "2005-01-01"},
+		{"month name with comma", mdy, "Jan 1, 2005", "2005-01-01"},
+		{"abbreviation with dot", mdy, "Sept. 9", "09-09"},
+		{"month the ordinal", mdy, "March the 3rd", "03-03"},
+		{"day month", mdy, "1 January 2005", "2005-01-01"},
+		{"ordinal day month", dmy, "1st Jan", "01-01"},
+		{"the 3rd of March", mdy, "the 3rd of March", "03-03"},
+		{"the 3rd of March with year", mdy, "the 3rd of March 1999", "1999-03-03"},
+		{"case insensitive", mdy, "DECEMBER 25", "12-25"},
+		{"leap day without year", mdy, "02-29", "02-29"},
+		{"leap day in a leap year", mdy, "2024-02-29", "2024-02-29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := tt.parser.Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if got := d.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	p := Parser{Now: fixedNow}
+
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantMatch string
+	}{
+		{"in a sentence", "remember Ada on 12/10 please", "12-10", "12/10"},
+		{"earliest match wins", "she was born 3/4 not 5/6", "03-04", "3/4"},
+		{"longest match at the same place", "born 12/25/1990 in Lagos", "1990-12-25", "12/25/1990"},
+		{"year joins a month name", "Ada, March 3rd, 1990", "1990-03-03", "March 3rd, 1990"},
+		{"ordinal before month", "it's on the 3rd of March", "03-03", "the 3rd of March"},
+		{"decimals are not dates", "version 2.5 ships 1/2", "01-02", "1/2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, match, err := p.Find(tt.text)
+			if err != nil {
+				t.Fatalf("Find(%q): %v", tt.text, err)
+			}
+			if d.String() != tt.want || match != tt.wantMatch {
+				t.Errorf("Find(%q) = %s from %q, want %s from %q", tt.text, d, match, tt.want, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	p := Parser{Now: fixedNow}
+
+	tests := []struct {
+		text   string
+		reason string
+	}{
+		{"02-30", "February has only 29 days"},
+		{"2023-02-29", "2023 is not a leap year"},
+		{"04/31/1990", "April has only 30 days"},
+		{"13/13", "month 13 is out of range"},
+		{"00/10", "month 00 is out of range"},
+		{"1990-01-00", "day 00 is out of range"},
+		{"31.02.1990", "1990 is not a leap year"},
+		{"31/04", "April has only 30 days"},
+		{"remember 1/2", "unexpected text around the date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := p.Parse(tt.text)
+			var invalid *InvalidDateError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Parse(%q) error = %v, want *InvalidDateError", tt.text, err)
+			}
+			if invalid.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", invalid.Reason, tt.reason)
+			}
+			if invalid.Text != tt.text {
+				t.Errorf("error text = %q, want %q", invalid.Text, tt.text)
+			}
+		})
+	}
+}
+
+func TestParseNoDate(t *testing.T) {
+	for _, text := range []string{"", "hello there", "version 2.5", "May I help?"} {
+		if _, err := (Parser{}).Parse(text); !errors.Is(err, ErrNoDate) {
+			t.Errorf("Parse(%q) error = %v, want ErrNoDate", text, err)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	tests := map[string]Order{"": MonthFirst, "mdy": MonthFirst, "DMY": DayFirst, "day-first": DayFirst}
+	for s, want := range tests {
+		if got, err := ParseOrder(s); err != nil || got != want {
+			t.Errorf("ParseOrder(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseOrder("ymd"); err == nil {
+		t.Error("ParseOrder(ymd) succeeded, want error")
+	}
+}
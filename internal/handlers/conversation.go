@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/intent"
+	"hazel_ai/internal/store"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metadata keys that identify the sender, so two people chatting in the same
+// context don't answer each other's questions
+var userMetadataKeys = []string{"telex_user_id", "user_id", "userId", "sender_id"}
+
+// yearPattern finds a four-digit year in a follow-up answer
+var yearPattern = regexp.MustCompile(`\b(\d{4})\b`)
+
+// yearAnswerPattern is a year answer with the words around it ("she was
+// born in 1990"), taken out before checking for a new request
+var yearAnswerPattern = regexp.MustCompile(`(?i)(\b(was|were)\s+)?(\bborn\s+(in\s+)?)?\b\d{4}\b`)
+
+// cancelPhrases abandon whatever Hazel is waiting for
+var cancelPhrases = []string{"cancel", "never mind", "nevermind", "forget it", "stop"}
+
+// skipPhrases decline an optional question
+var skipPhrases = []string{"skip", "no", "nope", "don't know", "dont know", "not sure", "no idea", "dunno"}
+
+// conversationKey identifies the conversation an A2A request belongs to: its
+// context ID, or task ID when there is no context, plus the sender when the
+// metadata names one. Requests without either can't be followed up.
+func conversationKey(params protocol.MessageSendParams) string {
+	id := ""
+	if contextID := params.EffectiveContextID(); contextID != "" {
+		id = "contextId:" + contextID
+	} else if taskID := params.EffectiveTaskID(); taskID != "" {
+		id = "taskId:" + taskID
+	}
+	if id == "" {
+		return ""
+	}
+
+	key := a2aTenant(params) + "|" + id
+	if user := lookupString(requestMetadata(params), userMetadataKeys); user != "" {
+		key += "|" + user
+	}
+	return key
+}
+
+// continueConversation treats the message as the answer to the question
+// Hazel last asked in this conversation. It reports false when there was no
+// question or the message doesn't answer it, so it can be handled as a new
+// request instead.
+func (h *Handler) continueConversation(m intent.Message) (intent.Reply, bool) {
+	key, text, lower := m.ConversationKey, m.Text, m.Lower
+	state, ok := h.conversations.Get(key)
+	if !ok {
+		return intent.Reply{}, false
+	}
+
+	if matchesPhrase(lower, cancelPhrases) {
+		h.conversations.Clear(key)
+		return intent.Reply{Text: "👍 Okay, never mind. What else can I do for you?"}, true
+	}
+
+	switch state.Awaiting {
+	case conversation.SlotName:
+		// "list birthdays" or "help" is a new request, not a name
+		if h.startsOtherIntent(m, state) {
+			break
+		}
+		name, self := answerName(text)
+		if self {
+			// Asks for a name again if the metadata doesn't carry one
+			name = senderDisplayName(m.Params)
+			state.Slots[conversation.SlotSelf] = "true"
+		} else if name == "" {
+			break
+		}
+		state.Slots[conversation.SlotName] = name
+		return h.advance(m, state), true
+
+	case conversation.SlotDate:
+		// "list birthdays", or a date for someone else, is a new request
+		if h.startsNewRequest(m, state) {
+			break
+		}
+		date, _, err := h.dates.Find(text)
+		var invalid *dateparse.InvalidDateError
+		if errors.As(err, &invalid) {
+			h.conversations.Put(key, state)
+			response := fmt.Sprintf("❌ %s isn't a real date: %s. What's the right date?", invalid.Text, invalid.Reason)
+			return intent.Reply{Text: response}, true
+		}
+		if err != nil {
+			break
+		}
+		fillDate(&state, date)
+		return h.advance(m, state), true
+
+	case conversation.SlotYear:
+		if matchesPhrase(lower, skipPhrases) {
+			h.conversations.Clear(key)
+			return intent.Reply{Text: "👍 No problem, I'll keep it without the year."}, true
+		}
+		if h.startsNewRequest(m, state) {
+			break
+		}
+		year := yearPattern.FindString(text)
+		if year == "" {
+			break
+		}
+		return h.fillBirthYear(m, state, year), true
+
+	case conversation.SlotCandidates, conversation.SlotConfirm, conversation.SlotUndo:
+		if reply, handled := h.answerManage(m, state); handled {
+			return reply, true
+		}
+	}
+
+	// Not an answer: drop the question and treat the message as a new request
+	log.Printf("Message doesn't answer the pending %s question, starting over", state.Awaiting)
+	h.conversations.Clear(key)
+	return intent.Reply{}, false
+}
+
+// startsOtherIntent reports whether the message reads as a command for a
+// different intent than the one waiting for an answer
+func (h *Handler) startsOtherIntent(m intent.Message, state conversation.State) bool {
+	result := h.router.Route(m)
+	if result.Ambiguous() {
+		return true
+	}
+	return result.Best != nil && result.Best.Name() != state.Intent
+}
+
+// startsNewRequest reports whether a message sent while Hazel waits for a
+// date or year is a new request rather than the answer: it is about someone
+// other than the person asked about, or what is left once the date and
+// year are taken out reads as a command for a different intent
+func (h *Handler) startsNewRequest(m intent.Message, state conversation.State) bool {
+	if name := state.Slots[conversation.SlotName]; name != "" {
+		if m.Self && !state.Has(conversation.SlotSelf) {
+			return true
+		}
+		for _, other := range m.Names {
+			if !strings.EqualFold(other, name) {
+				return true
+			}
+		}
+	}
+
+	rest := m
+	rest.Text = yearAnswerPattern.ReplaceAllString(m.TextWithoutDate(), " ")
+	rest.Lower = strings.ToLower(strings.TrimSpace(rest.Text))
+	rest.Date, rest.DateText, rest.DateErr = nil, "", nil
+	return h.startsOtherIntent(rest, state)
+}
+
+// advance continues the pending intent once a slot has been filled
+func (h *Handler) advance(m intent.Message, state conversation.State) intent.Reply {
+	if state.Intent == conversation.IntentRemember {
+		return h.advanceRemember(m, state)
+	}
+	return h.advanceManage(m, state)
+}
+
+// rememberSlots reads whatever the message says about whose birthday it is
+// and when. The reply is non-empty when the message can't be used as-is.
+func (h *Handler) rememberSlots(m intent.Message) (conversation.State, string) {
+	state := conversation.State{Intent: conversation.IntentRemember, Slots: map[string]string{}}
+
+	var invalid *dateparse.InvalidDateError
+	if errors.As(m.DateErr, &invalid) {
+		return state, fmt.Sprintf("❌ %s isn't a real date: %s. Could you check it and try again?", invalid.Text, invalid.Reason)
+	}
+	if m.Date != nil {
+		fillDate(&state, *m.Date)
+	}
+
+	names := m.Names
+	// "remember to wish Alice" names her after the verb
+	if len(names) == 0 && !m.Self && mentions(m, reminderPhrases...) {
+		if person, _ := targetName(m.TextWithoutDate(), "wish", "congratulate", "greet", "celebrate"); person != "" {
+			names = []string{person}
+		}
+	}
+
+	switch {
+	case len(names) > 1:
+		return state, fmt.Sprintf("🤔 Whose birthday is it: %s? Try 'Remember %s's birthday is %s'.",
+			strings.Join(names, " or "), names[0], orExample(m.DateText))
+	case len(names) == 1:
+		state.Slots[conversation.SlotName] = names[0]
+	case m.Self:
+		state.Slots[conversation.SlotSelf] = "true"
+		state.Slots[conversation.SlotName] = senderDisplayName(m.Params)
+	}
+	return state, ""
+}
+
+// advanceRemember asks for the next missing slot of a remember request, or
+// saves the birthday once the name and date are known
+func (h *Handler) advanceRemember(m intent.Message, state conversation.State) intent.Reply {
+	key := m.ConversationKey
+	self := state.Has(conversation.SlotSelf)
+	name := state.Slots[conversation.SlotName]
+
+	whose, who := name+"'s", name
+	if self {
+		whose, who = "your", "you"
+	}
+
+	switch {
+	case name == "" && self:
+		state.Awaiting = conversation.SlotName
+		h.conversations.Put(key, state)
+		return intent.Reply{Text: "🤔 What name should I save your birthday under?"}
+
+	case name == "":
+		state.Awaiting = conversation.SlotName
+		h.conversations.Put(key, state)
+		response := "🤔 Whose birthday is it? Tell me their name, or say 'mine'."
+		if state.Has(conversation.SlotDate) {
+			response = fmt.Sprintf("🤔 Whose birthday is %s? Tell me their name, or say 'mine'.", formatSlotDate(state))
+		}
+		return intent.Reply{Text: response}
+
+	case !state.Has(conversation.SlotDate):
+		state.Awaiting = conversation.SlotDate
+		h.conversations.Put(key, state)
+		response := fmt.Sprintf("📅 When is %s birthday? (like 2005-01-01 or January 1st)", whose)
+		return intent.Reply{Text: response}
+	}
+
+	date := state.Slots[conversation.SlotDate]
+	if state.Has(conversation.SlotYear) {
+		date = state.Slots[conversation.SlotYear] + "-" + date
+	}
+
+	id, err := h.birthdayStore.AddBirthday(store.NewBirthday{Name: name, Date: date, Tenant: m.Tenant})
+	if err != nil {
+		h.conversations.Clear(key)
+		response := fmt.Sprintf("❌ Sorry, I couldn't store %s birthday. Error: %s", whose, err.Error())
+		return intent.Reply{Text: response, Failed: true}
+	}
+	log.Printf("Successfully stored birthday for %s: %s (ID: %s)", name, date, id)
+
+	response := fmt.Sprintf("🎂 Perfect! I've remembered %s birthday is on %s. I'll make sure to wish %s a happy birthday! 🎉",
+		whose, formatSlotDate(state), who)
+	var data map[string]any
+	if b, err := h.birthdayStore.Get(m.Tenant, id); err == nil {
+		data = fiber.Map{"saved": []birthdayView{h.newBirthdayView(b, time.Now())}}
+	}
+
+	// Offer to add the year so ages can be shown, when we can hear the answer
+	if state.Has(conversation.SlotYear) || key == "" {
+		h.conversations.Clear(key)
+		return intent.Reply{Text: response, Data: data}
+	}
+
+	state.Slots[conversation.SlotID] = id
+	state.Awaiting = conversation.SlotYear
+	h.conversations.Put(key, state)
+	if self {
+		response += "\n\nWhat year were you born? (or say 'skip')"
+	} else {
+		response += fmt.Sprintf("\n\nWhat year was %s born? (or say 'skip')", name)
+	}
+	return intent.Reply{Text: response, Data: data}
+}
+
+// fillBirthYear adds the year given in a follow-up to the saved birthday
+func (h *Handler) fillBirthYear(m intent.Message, state conversation.State, year string) intent.Reply {
+	key := m.ConversationKey
+	date := year + "-" + state.Slots[conversation.SlotDate]
+	birthday, err := h.birthdayStore.Patch(m.Tenant, state.Slots[conversation.SlotID], store.BirthdayPatch{Date: &date})
+	if errors.Is(err, store.ErrInvalidDate) {
+		h.conversations.Put(key, state)
+		response := fmt.Sprintf("❌ %s doesn't work for that birthday: %s. What year was it?", year, err.Error())
+		return intent.Reply{Text: response}
+	}
+	h.conversations.Clear(key)
+	if err != nil {
+		response := fmt.Sprintf("❌ Sorry, I couldn't add the year. Error: %s", err.Error())
+		return intent.Reply{Text: response, Failed: true}
+	}
+
+	response := fmt.Sprintf("🎂 Got it, %s was born in %s.", birthday.Name, year)
+	if turning, ok := h.calendar.TurningOn(birthday, time.Now()); ok {
+		response = fmt.Sprintf("🎂 Got it, %s was born in %s and turns %d next birthday.", birthday.Name, year, turning)
+	}
+	return intent.Reply{Text: response}
+}
+
+// fillDate stores a parsed date in the date and year slots
+func fillDate(state *conversation.State, date dateparse.Date) {
+	state.Slots[conversation.SlotDate] = fmt.Sprintf("%02d-%02d", date.Month, date.Day)
+	if date.HasYear() {
+		state.Slots[conversation.SlotYear] = strconv.Itoa(date.Year)
+	}
+}
+
+// formatSlotDate shows the date slot as "March 3"
+func formatSlotDate(state conversation.State) string {
+	var month, day int
+	fmt.Sscanf(state.Slots[conversation.SlotDate], "%d-%d", &month, &day)
+	return fmt.Sprintf("%s %d", time.Month(month), day)
+}
+
+// answerName reads a reply to "whose birthday is it?": a bare name, a
+// sentence naming someone, or "mine"
+func answerName(text string) (string, bool) {
+	match := extractBirthdayName(text)
+	if len(match.Names) == 1 {
+		return match.Names[0], false
+	}
+	if len(match.Names) > 1 {
+		return "", false
+	}
+
+	words := nameTokens(text)
+	if match.Self || (len(words) == 1 && (strings.EqualFold(words[0], "mine") || selfWords[strings.ToLower(words[0])])) {
+		return "", true
+	}
+	if len(words) > maxNameWords {
+		return "", false
+	}
+	return nameAfter(words), false
+}
+
+// matchesPhrase reports whether the whole message is one of the phrases
+func matchesPhrase(text string, phrases []string) bool {
+	text = strings.Trim(text, " .!")
+	for _, phrase := range phrases {
+		if text == phrase {
+			return true
+		}
+	}
+	return false
+}
+
+func orExample(dateText string) string {
+	if dateText == "" {
+		return "2005-01-01"
+	}
+	return dateText
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"hazel_ai/internal/store"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnswerName(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantName string
+		wantSelf bool
+	}{
+		{"Alice", "Alice", false},
+		{"mary jane", "Mary", false},
+		{"Mary Jane", "Mary Jane", false},
+		{"mine", "", true},
+		{"it's my birthday", "", true},
+		{"list", "", false},
+		{"list birthdays", "", false},
+		{"show upcoming", "", false},
+		{"help", "", false},
+		{"today", "", false},
+		{"tomorrow", "", false},
+		{"all birthdays", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			name, self := answerName(tt.text)
+			if name != tt.wantName || self != tt.wantSelf {
+				t.Errorf("answerName(%q) = %q, %v; want %q, %v", tt.text, name, self, tt.wantName, tt.wantSelf)
+			}
+		})
+	}
+}
+
+func TestNameQuestionAnsweredWithName(t *testing.T) {
+	s := newTestServer(t)
+
+	reply := s.chat(t, "ctx", "Remember March 3rd")
+	if !strings.Contains(reply, "Whose birthday") {
+		t.Fatalf("reply = %q, want a question about whose birthday it is", reply)
+	}
+
+	reply = s.chat(t, "ctx", "Alice")
+	if !strings.Contains(reply, "Alice's birthday is on March 3") {
+		t.Fatalf("reply = %q, want Alice saved", reply)
+	}
+}
+
+func TestNameQuestionLeftForOtherCommands(t *testing.T) {
+	for _, command := range []string{"list birthdays", "help", "show upcoming", "upcoming birthdays", "today", "list"} {
+		t.Run(command, func(t *testing.T) {
+			s := newTestServer(t)
+
+			if reply := s.chat(t, "ctx", "Remember March 3rd"); !strings.Contains(reply, "Whose birthday") {
+				t.Fatalf("reply = %q, want a question about whose birthday it is", reply)
+			}
+			s.chat(t, "ctx", command)
+
+			saved, err := s.store.List(store.AllTenants)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != 0 {
+				t.Fatalf("%q was saved as %q's birthday", command, saved[0].Name)
+			}
+
+			// The question was dropped, so a bare name isn't taken as an answer
+			s.chat(t, "ctx", "Alice")
+			if saved, _ := s.store.List(store.AllTenants); len(saved) != 0 {
+				t.Errorf("name saved after the conversation was left: %+v", saved)
+			}
+		})
+	}
+}
+
+// savedBirthdays lists the test context's book as "Name MM-DD" or
+// "Name YYYY-MM-DD"
+func savedBirthdays(t *testing.T, s *testServer) []string {
+	t.Helper()
+	saved, err := s.store.List(testTenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, b := range saved {
+		date := fmt.Sprintf("%02d-%02d", b.Month, b.Day)
+		if b.Year != 0 {
+			date = fmt.Sprintf("%d-%s", b.Year, date)
+		}
+		out = append(out, b.Name+" "+date)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestNewRequestWhileAnswering(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     []string
+	}{
+		{
+			name:     "year question",
+			messages: []string{"Remember Alice's birthday is March 3", "Remember Bob's birthday is 1990-05-05"},
+			want:     []string{"Alice 03-03", "Bob 1990-05-05"},
+		},
+		{
+			name:     "date question",
+			messages: []string{"Remember Carol's birthday", "Remember Dan's birthday is April 4"},
+			want:     []string{"Dan 04-04"},
+		},
+		{
+			// Without a display name Hazel asks what to save it under
+			name:     "year question then my own birthday",
+			messages: []string{"Remember Alice's birthday is March 3", "Remember my birthday is 1985-07-01"},
+			want:     []string{"Alice 03-03"},
+		},
+		{
+			name:     "date question then a list",
+			messages: []string{"Remember Carol's birthday", "list birthdays", "April 4"},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			for _, message := range tt.messages {
+				s.chat(t, "ctx", message)
+			}
+			if got := savedBirthdays(t, s); !slices.Equal(got, tt.want) {
+				t.Errorf("saved %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateAndYearAnswers(t *testing.T) {
+	tests := []struct {
+		name      string
+		messages  []string
+		wantReply string
+		want      []string
+	}{
+		{
+			name:      "date",
+			messages:  []string{"Remember Carol's birthday", "March 5"},
+			wantReply: "Carol's birthday is on March 5",
+			want:      []string{"Carol 03-05"},
+		},
+		{
+			name:      "date naming the same person",
+			messages:  []string{"Remember Carol's birthday", "Carol's is on March 5"},
+			wantReply: "Carol's birthday is on March 5",
+			want:      []string{"Carol 03-05"},
+		},
+		{
+			name:      "year",
+			messages:  []string{"Remember Carol's birthday is March 5", "1990"},
+			wantReply: "Carol was born in 1990",
+			want:      []string{"Carol 1990-03-05"},
+		},
+		{
+			name:      "year in a sentence",
+			messages:  []string{"Remember Carol's birthday is March 5", "she was born in 1990"},
+			wantReply: "Carol was born in 1990",
+			want:      []string{"Carol 1990-03-05"},
+		},
+		{
+			name:      "new date for a change",
+			messages:  []string{"Remember Bob's birthday is March 3", "skip", "Change Bob's birthday", "April 4"},
+			wantReply: "from March 3 to April 4",
+			want:      []string{"Bob 03-03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			var reply string
+			for _, message := range tt.messages {
+				reply = s.chat(t, "ctx", message)
+			}
+			if !strings.Contains(reply, tt.wantReply) {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+			if got := savedBirthdays(t, s); !slices.Equal(got, tt.want) {
+				t.Errorf("saved %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
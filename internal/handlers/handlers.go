@@ -1,14 +1,22 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	a2alogic "hazel_ai/internal/a2a"
-	"hazel_ai/internal/agent"
+	"hazel_ai/internal/a2a/protocol"
 	"hazel_ai/internal/clients"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/intent"
 	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"hazel_ai/internal/wishsession"
 	"log"
 	"net/http"
-	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,21 +25,35 @@ import (
 )
 
 type Handler struct {
-	birthdayStore *store.BirthdayStore
-	geminiClient  *clients.GeminiClient
+	birthdayStore store.Repository
+	calendar      store.Calendar
+	wishes        clients.WishGenerator
+	wishSessions  *wishsession.Store
+	wishHistory   *store.WishHistory
+	tasks         *task.Store
+	pushConfigs   *store.PushConfigs
+	pusher        *a2alogic.PushSender
+	toolCaller    *clients.GeminiClient
+	reminder      *a2alogic.Reminder
+	dates         dateparse.Parser
+	conversations *conversation.Store
+	router        *intent.Router
 }
 
-func NewHandler(birthdayStore *store.BirthdayStore) *Handler {
-	geminiClient, err := clients.NewGeminiClient()
-	if err != nil {
-		log.Printf("Warning: Failed to initialize Gemini client: %v", err)
-		geminiClient = nil
-	}
-
-	return &Handler{
+func NewHandler(birthdayStore store.Repository, calendar store.Calendar, dates dateparse.Parser, conversations *conversation.Store, wishes clients.WishGenerator, wishSessions *wishsession.Store, wishHistory *store.WishHistory, tasks *task.Store, reminder *a2alogic.Reminder) *Handler {
+	h := &Handler{
 		birthdayStore: birthdayStore,
-		geminiClient:  geminiClient,
-	}
+		calendar:      calendar,
+		dates:         dates,
+		conversations: conversations,
+		wishes:        wishes,
+		wishSessions:  wishSessions,
+		wishHistory:   wishHistory,
+		tasks:         tasks,
+		reminder:      reminder,
+	}
+	h.router = h.newRouter()
+	return h
 }
 
 func (h *Handler) Health(c *fiber.Ctx) error {
@@ -43,17 +65,20 @@ func (h *Handler) Health(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetAgentCard(c *fiber.Ctx) error {
-	agentCard, err := agent.LoadDefaultAgentCard()
+	agentCard, err := h.agentCard()
 	if err != nil {
 		return err
 	}
-	return c.Status(http.StatusOK).Send(agentCard)
+	return c.Status(http.StatusOK).JSON(agentCard)
 }
 
 func (h *Handler) AddBirthday(c *fiber.Ctx) error {
 	type AddBirthdayRequest struct {
-		Name string `json:"name"`
-		Date string `json:"date"`
+		Name      string   `json:"name"`
+		Date      string   `json:"date"`
+		HideYear  bool     `json:"hide_year"`
+		Interests []string `json:"interests"`
+		Notes     string   `json:"notes"`
 	}
 
 	var req AddBirthdayRequest
@@ -61,487 +86,576 @@ func (h *Handler) AddBirthday(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	id, err := h.birthdayStore.AddBirthday(req.Name, req.Date)
+	date, err := h.normalizeDate(req.Date)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Failed to add birthday: " + err.Error()})
+	}
+
+	id, err := h.birthdayStore.AddBirthday(store.NewBirthday{Name: req.Name, Date: date, HideYear: req.HideYear, Interests: req.Interests, Notes: req.Notes, Tenant: restTenant(c)})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to add birthday: " + err.Error()})
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": "Failed to add birthday: " + err.Error()})
 	}
 
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Birthday added successfully",
 		"name":    req.Name,
-		"date":    req.Date,
+		"date":    date,
 		"id":      id,
 	})
 }
 
 func (h *Handler) ListBirthdays(c *fiber.Ctx) error {
-	birthdays := h.birthdayStore.List()
+	birthdays, err := h.birthdayStore.List(restTenant(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to list birthdays: " + err.Error()})
+	}
 	return c.Status(200).JSON(fiber.Map{
-		"birthdays": birthdays,
+		"birthdays": h.newBirthdayViews(birthdays, time.Now()),
 		"total":     len(birthdays),
 	})
 }
 
 func (h *Handler) GetTodaysBirthdays(c *fiber.Ctx) error {
 	today := time.Now()
-	birthdays := h.birthdayStore.List()
-
-	var todaysBirthdays []store.Birthday
-	for _, b := range birthdays {
-		if b.Month == int(today.Month()) && b.Day == today.Day() {
-			todaysBirthdays = append(todaysBirthdays, b)
-		}
+	todaysBirthdays, err := store.BirthdaysOn(h.birthdayStore, h.calendar, restTenant(c), today)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to list birthdays: " + err.Error()})
 	}
 
 	return c.Status(200).JSON(fiber.Map{
-		"birthdays": todaysBirthdays,
+		"birthdays": h.newBirthdayViews(todaysBirthdays, today),
 		"count":     len(todaysBirthdays),
 	})
 }
 
 func (h *Handler) GetUpcomingBirthdays(c *fiber.Ctx) error {
 	now := time.Now()
-	birthdays := h.birthdayStore.List()
+	birthdays, err := h.birthdayStore.List(restTenant(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to list birthdays: " + err.Error()})
+	}
 
+	// Today's birthdays have their own endpoint
 	var upcoming []store.Birthday
-	for _, b := range birthdays {
-		thisYear := time.Date(now.Year(), time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		if thisYear.Before(now) {
-			thisYear = time.Date(now.Year()+1, time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		}
-
-		daysUntil := int(thisYear.Sub(now).Hours() / 24)
-		if daysUntil <= 30 && daysUntil > 0 {
-			upcoming = append(upcoming, b)
+	for _, u := range h.upcomingBirthdays(birthdays, now, 30) {
+		if u.daysUntil > 0 {
+			upcoming = append(upcoming, u.Birthday)
 		}
 	}
 
 	return c.Status(200).JSON(fiber.Map{
-		"birthdays": upcoming,
+		"birthdays": h.newBirthdayViews(upcoming, now),
 		"count":     len(upcoming),
 	})
 }
 
-func (h *Handler) SendA2AMessage(c *fiber.Ctx) error {
-	// Handle both simple A2A messages and Telex JSONRPC format
-	var telexRequest map[string]interface{}
-	if err := c.BodyParser(&telexRequest); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid A2A message"})
+// GetBirthday returns a single birthday by ID
+func (h *Handler) GetBirthday(c *fiber.Ctx) error {
+	birthday, err := h.birthdayStore.Get(restTenant(c), c.Params("id"))
+	if err != nil {
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
+	return c.Status(200).JSON(h.newBirthdayView(birthday, time.Now()))
+}
 
-	log.Printf("Received A2A request: %+v", telexRequest)
-
-	// Check if this is a valid Telex JSONRPC request
-	if jsonrpc, ok := telexRequest["jsonrpc"].(string); ok && jsonrpc == "2.0" {
-		// Check for the correct method
-		if method, ok := telexRequest["method"].(string); ok {
-			if method != "message/send" {
-				log.Printf("Unsupported JSONRPC method: %s", method)
-				return c.Status(400).JSON(fiber.Map{
-					"jsonrpc": "2.0",
-					"id":      telexRequest["id"],
-					"error": fiber.Map{
-						"code":    -32601,
-						"message": "Method not found",
-					},
-				})
-			}
-		} else {
-			log.Printf("Missing method in JSONRPC request")
-			return c.Status(400).JSON(fiber.Map{"error": "Missing JSONRPC method"})
-		}
+// UpdateBirthday replaces a birthday's name and date
+func (h *Handler) UpdateBirthday(c *fiber.Ctx) error {
+	type UpdateBirthdayRequest struct {
+		Name      string   `json:"name"`
+		Date      string   `json:"date"`
+		HideYear  bool     `json:"hide_year"`
+		Interests []string `json:"interests"`
+		Notes     string   `json:"notes"`
 	}
 
-	// Extract text content from Telex JSONRPC format
-	var textContent string
+	var req UpdateBirthdayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Name == "" || req.Date == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Both name and date are required"})
+	}
 
-	// Check if this is a Telex JSONRPC request
-	if params, ok := telexRequest["params"].(map[string]interface{}); ok {
-		if message, ok := params["message"].(map[string]interface{}); ok {
-			if parts, ok := message["parts"].([]interface{}); ok && len(parts) > 0 {
-				if part, ok := parts[0].(map[string]interface{}); ok {
-					if text, ok := part["text"].(string); ok {
-						textContent = text
-					}
-				}
-			}
-		}
+	date, err := h.normalizeDate(req.Date)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Failed to update birthday: " + err.Error()})
 	}
 
-	// If no text content found, try simple format
-	if textContent == "" {
-		if content, ok := telexRequest["content"].(string); ok {
-			textContent = content
-		}
+	birthday, err := h.birthdayStore.Update(restTenant(c), c.Params("id"), store.NewBirthday{Name: req.Name, Date: date, HideYear: req.HideYear, Interests: req.Interests, Notes: req.Notes})
+	if err != nil {
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": "Failed to update birthday: " + err.Error()})
 	}
 
-	log.Printf("Extracted text content: %s", textContent)
-
-	// Process the text content
-	return h.processTextContent(c, textContent, telexRequest)
-}
-
-// processTextContent analyzes text and determines what action to take
-func (h *Handler) processTextContent(c *fiber.Ctx, text string, originalRequest map[string]interface{}) error {
-	originalText := text
-	text = strings.ToLower(strings.TrimSpace(text))
-	log.Printf("Processing text content: '%s'", text)
-
-	// Check if text contains dates - prioritize remember requests with dates
-	hasDate := isDateFormat(text)
-	hasRemember := strings.Contains(text, "remember") || strings.Contains(text, "my birthday")
-	hasWish := strings.Contains(text, "birthday wish") || strings.Contains(text, "wish") ||
-		strings.Contains(text, "generate") || strings.Contains(text, "random")
-	hasList := strings.Contains(text, "list") || strings.Contains(text, "show birthdays")
-	hasUpcoming := strings.Contains(text, "upcoming") || strings.Contains(text, "coming up")
-
-	// Priority: Remember with date > Wish > Upcoming > List > Remember without date
-	if hasRemember && hasDate {
-		// This is a remember request with a date - handle it
-		return h.handleRememberRequest(c, originalText, originalRequest)
-	} else if hasWish {
-		return h.handleWishRequest(c, text, originalRequest)
-	} else if hasUpcoming && hasList {
-		return h.handleUpcomingRequest(c, originalRequest)
-	} else if hasList {
-		return h.handleListRequest(c, originalRequest)
-	} else if hasRemember {
-		return h.handleRememberRequest(c, originalText, originalRequest)
-	} else if hasDate {
-		// Handle date inputs as birthday storage requests
-		return h.handleDateInput(c, text, originalRequest)
-	} else {
-		// Generic response
-		response := "Hello! I'm Hazel, your birthday bot. I can help you with:\n• Generate birthday wishes\n• Remember birthdays\n• List stored birthdays\n• Show upcoming birthdays\n\nTry asking me to 'remember my birthday 2005-01-01' or 'generate a birthday wish'!"
-		return h.sendTelexResponse(c, response, originalRequest)
-	}
-}
-
-// isDateFormat checks if text contains date patterns
-func isDateFormat(text string) bool {
-	// Check for YYYY-MM-DD pattern
-	datePattern := `\d{4}-\d{1,2}-\d{1,2}`
-	matched, _ := regexp.MatchString(datePattern, text)
-	return matched
-}
-
-// handleDateInput processes date inputs as birthday storage
-func (h *Handler) handleDateInput(c *fiber.Ctx, text string, originalRequest map[string]interface{}) error {
-	response := fmt.Sprintf("I see you provided a date: %s. To store this as a birthday, please also provide a name. For example: 'Remember Alice's birthday is %s'", text, text)
-	return h.sendTelexResponse(c, response, originalRequest)
-}
-
-// handleWishRequest processes birthday wish requests
-func (h *Handler) handleWishRequest(c *fiber.Ctx, text string, originalRequest map[string]interface{}) error {
-	// Try to extract a name from the text
-	name := ""
-	words := strings.Fields(text)
-
-	// Look for patterns like "wish for John" or "birthday wish for Alice"
-	for i, word := range words {
-		if (word == "for" || word == "to") && i+1 < len(words) {
-			nextWord := words[i+1]
-			if len(nextWord) > 0 {
-				name = strings.ToUpper(string(nextWord[0])) + strings.ToLower(nextWord[1:])
-			} else {
-				name = nextWord
-			}
-			break
+	return c.Status(200).JSON(fiber.Map{
+		"message":  "Birthday updated successfully",
+		"birthday": h.newBirthdayView(birthday, time.Now()),
+	})
+}
+
+// PatchBirthday changes only the fields present in the request body
+func (h *Handler) PatchBirthday(c *fiber.Ctx) error {
+	var patch store.BirthdayPatch
+	if err := c.BodyParser(&patch); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if patch.Name == nil && patch.Date == nil && patch.HideYear == nil && patch.Interests == nil && patch.Notes == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Nothing to update - provide name, date, hide_year, interests and/or notes"})
+	}
+	if patch.Date != nil {
+		date, err := h.normalizeDate(*patch.Date)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Failed to update birthday: " + err.Error()})
 		}
+		patch.Date = &date
 	}
 
-	// If no specific name, generate a generic wish
-	if name == "" {
-		name = "you"
+	birthday, err := h.birthdayStore.Patch(restTenant(c), c.Params("id"), patch)
+	if err != nil {
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": "Failed to update birthday: " + err.Error()})
 	}
 
-	// Generate the birthday wish
-	var wish string
-	var source string
-
-	if h.geminiClient == nil {
-		wish = fmt.Sprintf("🎉 Happy Birthday%s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! May this year bring you endless blessings and amazing adventures! 🌟",
-			func() string {
-				if name == "you" {
-					return ""
-				} else {
-					return ", " + name
-				}
-			}())
-		source = "fallback"
-	} else {
-		var err error
-		if name == "you" {
-			wish, err = h.geminiClient.GenerateGenericBirthdayWish("friend")
-		} else {
-			wish, err = h.geminiClient.GenerateGenericBirthdayWish(name)
-		}
+	return c.Status(200).JSON(fiber.Map{
+		"message":  "Birthday updated successfully",
+		"birthday": h.newBirthdayView(birthday, time.Now()),
+	})
+}
 
-		if err != nil {
-			wish = fmt.Sprintf("🎉 Happy Birthday%s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! 🌟",
-				func() string {
-					if name == "you" {
-						return ""
-					} else {
-						return ", " + name
-					}
-				}())
-			source = "fallback"
-		} else {
-			source = "gemini"
-		}
+// DeleteBirthday removes a birthday by ID
+func (h *Handler) DeleteBirthday(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := h.birthdayStore.Delete(restTenant(c), id); err != nil {
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": "Failed to delete birthday: " + err.Error()})
 	}
 
-	log.Printf("Generated %s birthday wish for %s", source, name)
-	return h.sendTelexResponse(c, wish, originalRequest)
+	return c.Status(200).JSON(fiber.Map{
+		"message": "Birthday deleted successfully",
+		"id":      id,
+	})
 }
 
-// handleRememberRequest processes remember birthday requests
-func (h *Handler) handleRememberRequest(c *fiber.Ctx, text string, originalRequest map[string]interface{}) error {
-	log.Printf("DEBUG - handleRememberRequest received text: '%s'", text)
+// ListBirthdayWishes returns the wishes generated for a birthday, newest first
+func (h *Handler) ListBirthdayWishes(c *fiber.Ctx) error {
+	birthday, err := h.birthdayStore.Get(restTenant(c), c.Params("id"))
+	if err != nil {
+		return c.Status(storeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	wishes := []store.WishRecord{}
+	if h.wishHistory != nil {
+		wishes = h.wishHistory.List(birthday.ID)
+	}
+	return c.Status(200).JSON(fiber.Map{
+		"id":     birthday.ID,
+		"name":   birthday.Name,
+		"wishes": wishes,
+		"total":  len(wishes),
+	})
+}
 
-	// Try to extract date from the text using more flexible regex patterns
-	// Handle dates like "2005-01-01", "- 2003-09-09", "birthday 1995-12-25"
-	datePatterns := []string{
-		`\b\d{4}-\d{1,2}-\d{1,2}\b`,        // Standard YYYY-MM-DD
-		`-\s*\d{4}-\d{1,2}-\d{1,2}`,        // With preceding dash like "- 2003-09-09"
-		`birthday\s+\d{4}-\d{1,2}-\d{1,2}`, // With "birthday" prefix
+// birthdayView is a birthday as returned by the API, with the computed age.
+// Age and Turning are omitted when the birth year is unknown or hidden.
+type birthdayView struct {
+	store.Birthday
+	Age     *int `json:"age,omitempty"`
+	Turning *int `json:"turning,omitempty"`
+}
+
+func (h *Handler) newBirthdayView(b store.Birthday, now time.Time) birthdayView {
+	view := birthdayView{Birthday: b.Public()}
+	if age, ok := h.calendar.AgeOn(b, now); ok {
+		view.Age = &age
 	}
+	if turning, ok := h.calendar.TurningOn(b, now); ok {
+		view.Turning = &turning
+	}
+	return view
+}
 
-	var dateMatch string
-	for _, pattern := range datePatterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindString(text)
-		if match != "" {
-			// Extract just the date part (remove any prefix like "- " or "birthday ")
-			dateRe := regexp.MustCompile(`\d{4}-\d{1,2}-\d{1,2}`)
-			dateMatch = dateRe.FindString(match)
-			if dateMatch != "" {
-				break
-			}
+func (h *Handler) newBirthdayViews(birthdays []store.Birthday, now time.Time) []birthdayView {
+	views := make([]birthdayView, 0, len(birthdays))
+	for _, b := range birthdays {
+		views = append(views, h.newBirthdayView(b, now))
+	}
+	return views
+}
+
+// upcomingBirthday is a birthday with the number of days until it's celebrated
+type upcomingBirthday struct {
+	store.Birthday
+	daysUntil int
+}
+
+// upcomingView is an upcoming birthday as sent in chat replies' data
+type upcomingView struct {
+	birthdayView
+	DaysUntil int `json:"days_until"`
+}
+
+// upcomingBirthdays returns the birthdays celebrated within the next `days`
+// days (including today), soonest first
+func (h *Handler) upcomingBirthdays(birthdays []store.Birthday, now time.Time, days int) []upcomingBirthday {
+	var upcoming []upcomingBirthday
+	for _, b := range birthdays {
+		if daysUntil := h.calendar.DaysUntil(b, now); daysUntil <= days {
+			upcoming = append(upcoming, upcomingBirthday{Birthday: b, daysUntil: daysUntil})
 		}
 	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].daysUntil < upcoming[j].daysUntil })
+	return upcoming
+}
 
-	log.Printf("DEBUG - Date match found: '%s'", dateMatch)
+// turningSuffix returns " (turning N)" when the age is known, or ""
+func (h *Handler) turningSuffix(b store.Birthday, now time.Time) string {
+	if turning, ok := h.calendar.TurningOn(b, now); ok {
+		return fmt.Sprintf(" (turning %d)", turning)
+	}
+	return ""
+}
 
-	if dateMatch != "" {
-		// Try to store the birthday - use "User" as default name since no name was provided
-		name := "User" // Default name, could be enhanced to extract actual name
+// knownBirthday looks for the one stored birthday with this name, case
+// insensitively. It reports false when there is none or more than one.
+func (h *Handler) knownBirthday(tenant, name string) (store.Birthday, bool) {
+	birthdays, err := h.birthdayStore.List(tenant)
+	if err != nil {
+		return store.Birthday{}, false
+	}
 
-		id, err := h.birthdayStore.AddBirthday(name, dateMatch)
-		if err != nil {
-			response := fmt.Sprintf("❌ Sorry, I couldn't store your birthday. Error: %s", err.Error())
-			return h.sendTelexResponse(c, response, originalRequest)
+	var found []store.Birthday
+	for _, b := range birthdays {
+		if strings.EqualFold(b.Name, name) {
+			found = append(found, b)
 		}
+	}
+	if len(found) != 1 {
+		return store.Birthday{}, false
+	}
+	return found[0], true
+}
+
+// personalize fills in what the request leaves out from the birthday: the
+// age they turn next, unless the year is hidden, and their interests and
+// notes. It also steers clear of the wishes they got recently.
+func (h *Handler) personalize(req *clients.WishRequest, b store.Birthday) {
+	if req.Age == 0 {
+		req.Age, _ = h.calendar.TurningOn(b, time.Now())
+	}
+	if len(req.Interests) == 0 {
+		req.Interests = b.Interests
+	}
+	if req.Notes == "" {
+		req.Notes = b.Notes
+	}
+	if h.wishHistory != nil {
+		texts, templates := h.wishHistory.Recent(b.ID)
+		req.Avoid = append(req.Avoid, texts...)
+		req.AvoidTemplates = append(req.AvoidTemplates, templates...)
+	}
+}
 
-		// Parse the date to show it nicely
-		var response string
-		parsedDate, err := time.Parse("2006-01-02", dateMatch)
+// personalizeByName personalizes the request from the stored birthday with
+// the request's name, if there is exactly one, and returns that birthday
+func (h *Handler) personalizeByName(tenant string, req *clients.WishRequest) (store.Birthday, bool) {
+	b, ok := h.knownBirthday(tenant, req.Name)
+	if ok {
+		h.personalize(req, b)
+	}
+	return b, ok
+}
+
+// recordWish adds a wish generated for a stored birthday to its history
+func (h *Handler) recordWish(b store.Birthday, wish clients.Wish) {
+	if h.wishHistory == nil {
+		return
+	}
+	err := h.wishHistory.Add(store.WishRecord{
+		BirthdayID: b.ID,
+		Text:       wish.Text,
+		Source:     wish.Source,
+		Model:      wish.Model,
+		Template:   wish.Template,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record the wish for %s: %v", b.Name, err)
+	}
+}
+
+// normalizeDate reads a date in any format dateparse understands and returns
+// it in the store's YYYY-MM-DD or MM-DD form
+func (h *Handler) normalizeDate(date string) (string, error) {
+	d, err := h.dates.Parse(date)
+	if errors.Is(err, dateparse.ErrNoDate) {
+		return "", fmt.Errorf("%q is not a date I understand: try YYYY-MM-DD, MM-DD or \"January 1st 2005\"", date)
+	}
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+// storeErrorStatus maps store errors to HTTP status codes
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, store.ErrEmptyName), errors.Is(err, store.ErrInvalidDate), errors.Is(err, store.ErrTooLong):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// SendA2AMessage answers a chat message sent to the REST API, either as a
+// JSON-RPC request like the ones on POST / or in the simple
+// {"content": "..."} form, which gets the reply on its own
+func (h *Handler) SendA2AMessage(c *fiber.Ctx) error {
+	var simple struct {
+		JSONRPC *string `json:"jsonrpc"`
+		Content string  `json:"content"`
+	}
+	if err := json.Unmarshal(c.Body(), &simple); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid A2A message"})
+	}
+	if simple.JSONRPC != nil {
+		return h.HandleTelexA2A(c)
+	}
+
+	log.Printf("Extracted text content: %s", simple.Content)
+	m := h.newMessage(protocol.MessageSendParams{Message: protocol.NewMessage(protocol.RoleUser, simple.Content)})
+	log.Printf("Processing text content: '%s'", m.Lower)
+	return h.sendTelexResponse(c, h.reply(m))
+}
+
+// reply routes the message to the intent it is most likely meant for and
+// returns that intent's reply
+func (h *Handler) reply(m intent.Message) intent.Reply {
+	// Birthdays sent as data or files are saved as they are
+	if reply, handled := h.structuredReply(m); handled {
+		return reply
+	}
+
+	// A reply to a question Hazel asked finishes that request
+	if reply, handled := h.continueConversation(m); handled {
+		return reply
+	}
+
+	if h.toolCaller != nil {
+		reply, err := h.callTools(m)
 		if err == nil {
-			response = fmt.Sprintf("🎂 Perfect! I've remembered your birthday is on %s %d. I'll make sure to wish you a happy birthday! 🎉",
-				parsedDate.Month().String(), parsedDate.Day())
-		} else {
-			response = fmt.Sprintf("🎂 Great! I've stored your birthday (%s). I'll remember to celebrate with you! 🎉", dateMatch)
+			return reply
+		}
+		log.Printf("Tool calling failed, using the intent router: %v", err)
+	}
+
+	result := h.router.Route(m)
+	switch {
+	case result.Best != nil:
+		log.Printf("Routed message to %s intent", result.Best.Name())
+		return result.Best.Handle(m)
+	case result.Ambiguous():
+		log.Printf("Message is ambiguous between %d intents", len(result.Candidates))
+		return intent.Reply{Text: h.clarifyText(result.Candidates)}
+	default:
+		return intent.Reply{Text: h.helpText()}
+	}
+}
+
+// handleWish processes birthday wish requests
+func (h *Handler) handleWish(m intent.Message) intent.Reply {
+	// "a short funny wish for my manager Alice in Spanish"
+	req, text := parseWishOptions(m.TextWithoutDate())
+
+	// "wish Alice", "birthday wish for Bob", "send a happy birthday to Carol"
+	name, self := targetName(text, "wish", "congratulate", "greet", "to")
+
+	// If no specific name, generate a generic wish
+	if name == "" || self {
+		name = "you"
+	}
+	req.Name = name
+	return intent.Reply{Text: h.wishFor(m, req)}
+}
+
+// wishFor generates a birthday wish for req.Name, or a generic one when the
+// name is "you"
+func (h *Handler) wishFor(m intent.Message, req clients.WishRequest) string {
+	name := req.Name
+	if name == "you" {
+		req.Name = ""
+	}
+	if err := req.Validate(); err != nil {
+		return fmt.Sprintf("❌ I can't write that wish: %v", err)
+	}
+	var known *store.Birthday
+	if req.Name != "" {
+		if b, ok := h.personalizeByName(m.Tenant, &req); ok {
+			known = &b
 		}
+	}
+	wish := clients.StreamWishOrFallback(m.Context, h.wishes, req, m.Partial)
+	if known != nil {
+		h.recordWish(*known, wish)
+	}
 
-		log.Printf("Successfully stored birthday for %s: %s (ID: %s)", name, dateMatch, id)
-		return h.sendTelexResponse(c, response, originalRequest)
-	} else {
-		// No date found in the message
-		response := "I'd love to remember your birthday! Please tell me the date in YYYY-MM-DD format (like 2005-01-01) and I'll store it for you."
-		return h.sendTelexResponse(c, response, originalRequest)
+	log.Printf("Generated %s birthday wish for %s", wish.Source, name)
+	return wish.Text
+}
+
+// handleRemember processes remember birthday requests, asking for whatever
+// the message leaves out
+func (h *Handler) handleRemember(m intent.Message) intent.Reply {
+	state, reply := h.rememberSlots(m)
+	if reply != "" {
+		return intent.Reply{Text: reply}
 	}
+	return h.advanceRemember(m, state)
 }
 
-// handleListRequest processes list birthdays requests
-func (h *Handler) handleListRequest(c *fiber.Ctx, originalRequest map[string]interface{}) error {
-	birthdays := h.birthdayStore.List()
+// handleList processes list birthdays requests
+func (h *Handler) handleList(m intent.Message) intent.Reply {
+	birthdays, err := h.birthdayStore.List(m.Tenant)
+	if err != nil {
+		log.Printf("Error listing birthdays: %v", err)
+		return intent.Reply{Text: "❌ Sorry, I couldn't load the birthdays right now. Please try again later.", Failed: true}
+	}
 
+	now := time.Now()
+	data := fiber.Map{"birthdays": h.newBirthdayViews(birthdays, now)}
 	if len(birthdays) == 0 {
 		response := "📝 No birthdays stored yet! Ask me to 'remember your birthday' to get started."
-		return h.sendTelexResponse(c, response, originalRequest)
+		return intent.Reply{Text: response, Data: data}
 	}
 
 	response := fmt.Sprintf("🎂 Stored Birthdays (%d total):\n\n", len(birthdays))
 	for _, b := range birthdays {
-		response += fmt.Sprintf("• %s - %s %d\n", b.Name, time.Month(b.Month), b.Day)
+		response += fmt.Sprintf("• %s - %s %d%s\n", b.Name, time.Month(b.Month), b.Day, h.turningSuffix(b, now))
 	}
 
-	return h.sendTelexResponse(c, response, originalRequest)
+	return intent.Reply{Text: response, Data: data}
 }
 
-// handleUpcomingRequest processes upcoming birthdays requests
-func (h *Handler) handleUpcomingRequest(c *fiber.Ctx, originalRequest map[string]interface{}) error {
-	now := time.Now()
-	birthdays := h.birthdayStore.List()
+// handleUpcoming processes upcoming birthdays requests
+func (h *Handler) handleUpcoming(m intent.Message) intent.Reply {
+	return h.upcomingReply(m.Tenant, 30)
+}
 
-	var upcoming []store.Birthday
-	for _, b := range birthdays {
-		thisYear := time.Date(now.Year(), time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		if thisYear.Before(now) {
-			thisYear = time.Date(now.Year()+1, time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		}
+// upcomingReply lists the birthdays in the next days days
+func (h *Handler) upcomingReply(tenant string, days int) intent.Reply {
+	now := time.Now()
+	birthdays, err := h.birthdayStore.List(tenant)
+	if err != nil {
+		log.Printf("Error listing birthdays: %v", err)
+		return intent.Reply{Text: "❌ Sorry, I couldn't load the birthdays right now. Please try again later.", Failed: true}
+	}
 
-		daysUntil := int(thisYear.Sub(now).Hours() / 24)
-		if daysUntil <= 30 && daysUntil > 0 {
-			upcoming = append(upcoming, b)
-		}
+	upcoming := h.upcomingBirthdays(birthdays, now, days)
+	views := make([]upcomingView, 0, len(upcoming))
+	for _, u := range upcoming {
+		views = append(views, upcomingView{birthdayView: h.newBirthdayView(u.Birthday, now), DaysUntil: u.daysUntil})
 	}
+	data := fiber.Map{"days": days, "birthdays": views}
 
 	if len(upcoming) == 0 {
-		response := "📅 No upcoming birthdays in the next 30 days! All your saved birthdays are further away or already passed this year."
-		return h.sendTelexResponse(c, response, originalRequest)
+		response := fmt.Sprintf("📅 No upcoming birthdays in the next %d days! All your saved birthdays are further away or already passed this year.", days)
+		return intent.Reply{Text: response, Data: data}
 	}
 
-	response := fmt.Sprintf("🎂 Upcoming Birthdays (next 30 days):\n\n")
-	for _, b := range upcoming {
-		thisYear := time.Date(now.Year(), time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		if thisYear.Before(now) {
-			thisYear = time.Date(now.Year()+1, time.Month(b.Month), b.Day, 0, 0, 0, 0, now.Location())
-		}
-		daysUntil := int(thisYear.Sub(now).Hours() / 24)
+	response := fmt.Sprintf("🎂 Upcoming Birthdays (next %d days):\n\n", days)
+	for _, u := range upcoming {
+		b, daysUntil := u.Birthday, u.daysUntil
 
 		if daysUntil == 0 {
-			response += fmt.Sprintf("🎉 %s - TODAY! (%s %d)\n", b.Name, time.Month(b.Month), b.Day)
+			response += fmt.Sprintf("🎉 %s - TODAY! (%s %d)%s\n", b.Name, time.Month(b.Month), b.Day, h.turningSuffix(b, now))
 		} else if daysUntil == 1 {
-			response += fmt.Sprintf("🎂 %s - Tomorrow (%s %d)\n", b.Name, time.Month(b.Month), b.Day)
+			response += fmt.Sprintf("🎂 %s - Tomorrow (%s %d)%s\n", b.Name, time.Month(b.Month), b.Day, h.turningSuffix(b, now))
 		} else {
-			response += fmt.Sprintf("📅 %s - %d days (%s %d)\n", b.Name, daysUntil, time.Month(b.Month), b.Day)
+			response += fmt.Sprintf("📅 %s - %d days (%s %d)%s\n", b.Name, daysUntil, time.Month(b.Month), b.Day, h.turningSuffix(b, now))
 		}
 	}
 
-	return h.sendTelexResponse(c, response, originalRequest)
+	return intent.Reply{Text: response, Data: data}
 }
 
 // HandleTelexA2A handles all A2A requests from Telex via POST / endpoint
 func (h *Handler) HandleTelexA2A(c *fiber.Ctx) error {
-	var jsonrpcRequest map[string]interface{}
-	if err := c.BodyParser(&jsonrpcRequest); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"jsonrpc": "2.0",
-			"error": fiber.Map{
-				"code":    -32700,
-				"message": "Parse error",
-			},
-		})
-	}
-
-	log.Printf("Received A2A request: %+v", jsonrpcRequest)
-
-	// Validate JSON-RPC format
-	jsonrpc, ok := jsonrpcRequest["jsonrpc"].(string)
-	if !ok || jsonrpc != "2.0" {
-		return c.Status(400).JSON(fiber.Map{
-			"jsonrpc": "2.0",
-			"error": fiber.Map{
-				"code":    -32600,
-				"message": "Invalid Request",
-			},
-		})
-	}
-
-	// Get method and route accordingly
-	method, ok := jsonrpcRequest["method"].(string)
-	if !ok {
-		return c.Status(400).JSON(fiber.Map{
-			"jsonrpc": "2.0",
-			"id":      jsonrpcRequest["id"],
-			"error": fiber.Map{
-				"code":    -32600,
-				"message": "Invalid Request - missing method",
-			},
-		})
+	log.Printf("Received A2A request: %s", c.Body())
+
+	req, rpcErr := protocol.DecodeRequest(c.Body())
+	if rpcErr != nil {
+		log.Printf("Rejected A2A request: %v", rpcErr)
+		return h.rpcError(c, req, rpcErr)
 	}
 
-	log.Printf("A2A Method: %s", method)
+	log.Printf("A2A Method: %s", req.Method)
 
 	// Route based on method
-	switch method {
-	case "message/send":
-		return h.handleMessageSend(c, jsonrpcRequest)
+	switch req.Method {
+	case protocol.MethodMessageSend:
+		return h.handleMessageSend(c, req)
+	case protocol.MethodMessageStream:
+		return h.handleMessageStream(c, req)
+	case protocol.MethodTasksGet:
+		return h.handleTasksGet(c, req)
+	case protocol.MethodTasksCancel:
+		return h.handleTasksCancel(c, req)
+	case protocol.MethodPushConfigSet:
+		return h.handlePushConfigSet(c, req)
+	case protocol.MethodPushConfigGet:
+		return h.handlePushConfigGet(c, req)
+	case protocol.MethodPushConfigList:
+		return h.handlePushConfigList(c, req)
+	case protocol.MethodPushConfigDelete:
+		return h.handlePushConfigDelete(c, req)
 	default:
-		return c.Status(400).JSON(fiber.Map{
-			"jsonrpc": "2.0",
-			"id":      jsonrpcRequest["id"],
-			"error": fiber.Map{
-				"code":    -32601,
-				"message": "Method not found",
-			},
-		})
-	}
-}
-
-// handleMessageSend processes message/send A2A requests
-func (h *Handler) handleMessageSend(c *fiber.Ctx, jsonrpcRequest map[string]interface{}) error {
-	// Extract text content from Telex JSONRPC format
-	var textContent string
-
-	if params, ok := jsonrpcRequest["params"].(map[string]interface{}); ok {
-		if message, ok := params["message"].(map[string]interface{}); ok {
-			if parts, ok := message["parts"].([]interface{}); ok && len(parts) > 0 {
-				if part, ok := parts[0].(map[string]interface{}); ok {
-					if text, ok := part["text"].(string); ok {
-						textContent = text
-					}
-				}
-			}
-		}
+		return h.rpcError(c, req, protocol.MethodNotFound(req.Method))
 	}
+}
 
-	log.Printf("Extracted text content: %s", textContent)
-
-	if textContent == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"jsonrpc": "2.0",
-			"id":      jsonrpcRequest["id"],
-			"error": fiber.Map{
-				"code":    -32602,
-				"message": "Invalid params - no text content found",
-			},
-		})
-	}
-
-	// Process the text content
-	return h.processTextContent(c, textContent, jsonrpcRequest)
-}
-
-// sendTelexResponse sends a properly formatted response back to Telex
-func (h *Handler) sendTelexResponse(c *fiber.Ctx, message string, originalRequest map[string]interface{}) error {
-	log.Printf("Sending Telex response: %s", message)
-
-	// Check if this is a Telex JSONRPC request and respond accordingly
-	if jsonrpc, ok := originalRequest["jsonrpc"]; ok {
-		if id, ok := originalRequest["id"]; ok {
-			// Set explicit headers
-			c.Set("Content-Type", "application/json")
-
-			response := fiber.Map{
-				"jsonrpc": jsonrpc,
-				"id":      id,
-				"result": fiber.Map{
-					"message": fiber.Map{
-						"kind": "message",
-						"role": "assistant",
-						"parts": []fiber.Map{
-							{
-								"kind": "text",
-								"text": message,
-							},
-						},
-					},
-				},
-			}
-
-			log.Printf("Telex response structure: %+v", response)
-			return c.Status(200).JSON(response)
-		}
+// handleMessageSend processes message/send A2A requests. A notification,
+// sent without an ID, gets the reply on its own instead of a task.
+func (h *Handler) handleMessageSend(c *fiber.Ctx, req protocol.Request) error {
+	m, rpcErr := h.messageParams(c, req)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
 	}
+	log.Printf("Processing text content: '%s'", m.Lower)
 
-	// Fallback to simple response
-	return c.Status(200).JSON(fiber.Map{
+	if req.ID == nil {
+		return h.sendTelexResponse(c, h.reply(m))
+	}
+	return h.runTask(c, m, req)
+}
+
+// messageParams reads the params of a message/send or message/stream
+// request into a chat message. The text of every text part is read; a
+// message without text must carry a data or file part instead.
+func (h *Handler) messageParams(c *fiber.Ctx, req protocol.Request) (intent.Message, *protocol.Error) {
+	var params protocol.MessageSendParams
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		return intent.Message{}, rpcErr
+	}
+
+	text := params.Message.Text()
+	log.Printf("Extracted text content: %s", text)
+	structured := slices.ContainsFunc(params.Message.Parts, func(p protocol.Part) bool { return p.Kind != protocol.PartText })
+	if text == "" && !structured {
+		return intent.Message{}, protocol.InvalidParams("no text content found")
+	}
+	return h.newMessage(params), nil
+}
+
+// sendTelexResponse sends the reply to a request that doesn't take an A2A
+// task back
+func (h *Handler) sendTelexResponse(c *fiber.Ctx, reply intent.Reply) error {
+	log.Printf("Sending Telex response: %s", reply.Text)
+	response := fiber.Map{
 		"status":   "success",
-		"response": message,
-	})
+		"response": reply.Text,
+	}
+	if reply.Data != nil {
+		response["data"] = reply.Data
+	}
+	return c.Status(200).JSON(response)
 }
 
 // Old A2A handlers replaced with new Telex-compatible ones above
@@ -562,7 +676,8 @@ func (h *Handler) UseTelexWebhook(c *fiber.Ctx) error {
 	switch webhook.Event {
 	case "daily_check":
 		log.Println("Triggering daily birthday check...")
-		a2alogic.Remember()
+		report := h.reminder.Remember(c.Context())
+		return c.Status(200).JSON(fiber.Map{"status": "ok", "report": report})
 	default:
 		log.Printf("Unknown webhook event: %s", webhook.Event)
 	}
@@ -570,14 +685,16 @@ func (h *Handler) UseTelexWebhook(c *fiber.Ctx) error {
 	return c.Status(200).JSON(fiber.Map{"status": "ok"})
 }
 
-// GenerateBirthdayWish generates a personalized birthday wish using Gemini AI
+// GenerateBirthdayWish generates a personalized birthday wish. With a
+// count it writes that many candidates to pick from instead, like a wish
+// session that isn't kept.
 func (h *Handler) GenerateBirthdayWish(c *fiber.Ctx) error {
-	type WishRequest struct {
-		Name string `json:"name"`
-		Age  int    `json:"age,omitempty"`
+	type GenerateWishRequest struct {
+		wishBody
+		Count int `json:"count,omitempty"`
 	}
 
-	var req WishRequest
+	var req GenerateWishRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
@@ -585,39 +702,36 @@ func (h *Handler) GenerateBirthdayWish(c *fiber.Ctx) error {
 	if req.Name == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
 	}
-
-	if h.geminiClient == nil {
-		// Fallback to simple message if Gemini is not available
-		fallbackWish := "🎉 Happy Birthday, " + req.Name + "! 🎂 Wishing you all the joy and happiness on your special day! 🌟"
-		return c.Status(200).JSON(fiber.Map{
-			"name":   req.Name,
-			"wish":   fallbackWish,
-			"source": "fallback"})
+	count, ok := candidateCount(req.Count)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "count must be between 1 and 5"})
 	}
 
-	var wish string
-	var err error
-
-	if req.Age > 0 {
-		wish, err = h.geminiClient.GenerateBirthdayWish(req.Name, req.Age)
-	} else {
-		wish, err = h.geminiClient.GenerateGenericBirthdayWish(req.Name)
+	wishReq := req.request()
+	if err := wishReq.Validate(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-
-	if err != nil {
-		log.Printf("Error generating birthday wish: %v", err)
-		fallbackWish := "🎉 Happy Birthday, " + req.Name + "! 🎂 Wishing you all the joy and happiness on your special day! 🌟"
-		return c.Status(200).JSON(fiber.Map{
-			"name":   req.Name,
-			"wish":   fallbackWish,
-			"source": "fallback"})
+	b, known := h.personalizeByName(restTenant(c), &wishReq)
+	if req.Count == 0 {
+		return h.sendWish(c, wishReq, fiber.Map{}, b, known)
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"name":   req.Name,
-		"wish":   wish,
-		"age":    req.Age,
-		"source": "gemini"})
+	// Candidates are drafts, so they are kept out of the wish history
+	candidates := clients.CandidatesOrFallback(c.Context(), h.wishes, wishReq, count)
+	response := fiber.Map{
+		"name":       wishReq.Name,
+		"wish":       candidates[0].Text,
+		"source":     candidates[0].Source,
+		"tone":       wishReq.Tone,
+		"candidates": wishList(candidates),
+	}
+	if wishReq.Language != "" {
+		response["language"] = wishReq.Language
+	}
+	if wishReq.Age > 0 {
+		response["age"] = wishReq.Age
+	}
+	return c.Status(200).JSON(response)
 }
 
 // GenerateBirthdayWishForPerson generates a birthday wish for a specific person by ID
@@ -627,60 +741,28 @@ func (h *Handler) GenerateBirthdayWishForPerson(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Person ID is required"})
 	}
 
-	// Find the person in the birthday store
-	birthdays := h.birthdayStore.List()
-	var targetPerson *store.Birthday
-
-	for _, b := range birthdays {
-		if b.ID == personID {
-			targetPerson = &b
-			break
-		}
-	}
-
-	if targetPerson == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Person not found"})
+	wishReq, err := wishOptionsFromQuery(c)
+	if err == nil {
+		err = wishReq.Validate()
 	}
-
-	// For now, we'll generate generic wishes since we only store month/day
-	age := 0
-
-	if h.geminiClient == nil {
-		// Fallback message
-		fallbackWish := "🎉 Happy Birthday, " + targetPerson.Name + "! 🎂 Wishing you all the joy and happiness on your special day! 🌟"
-		return c.Status(200).JSON(fiber.Map{
-			"id":     targetPerson.ID,
-			"name":   targetPerson.Name,
-			"wish":   fallbackWish,
-			"source": "fallback"})
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	var wish string
-	var err error
-
-	if age > 0 {
-		wish, err = h.geminiClient.GenerateBirthdayWish(targetPerson.Name, age)
-	} else {
-		wish, err = h.geminiClient.GenerateGenericBirthdayWish(targetPerson.Name)
+	// Find the person in the birthday store
+	person, err := h.birthdayStore.Get(restTenant(c), personID)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Person not found"})
 	}
-
 	if err != nil {
-		log.Printf("Error generating birthday wish for %s: %v", targetPerson.Name, err)
-		fallbackWish := "🎉 Happy Birthday, " + targetPerson.Name + "! 🎂 Wishing you all the joy and happiness on your special day! 🌟"
-		return c.Status(200).JSON(fiber.Map{
-			"id":     targetPerson.ID,
-			"name":   targetPerson.Name,
-			"wish":   fallbackWish,
-			"source": "fallback"})
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to look up person: " + err.Error()})
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"id":     targetPerson.ID,
-		"name":   targetPerson.Name,
-		"wish":   wish,
-		"age":    age,
-		"source": "gemini",
-	})
+	// Use the age they are turning when the birth year is known and not hidden
+	wishReq.Name = person.Name
+	h.personalize(&wishReq, person)
+
+	return h.sendWish(c, wishReq, fiber.Map{"id": person.ID}, person, true)
 }
 
 // GenerateSimpleBirthdayWish generates a birthday wish with minimal input - just name required
@@ -690,54 +772,43 @@ func (h *Handler) GenerateSimpleBirthdayWish(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Name parameter is required"})
 	}
 
+	wishReq, err := wishOptionsFromQuery(c)
+	if err == nil {
+		err = wishReq.Validate()
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	wishReq.Name = name
+
 	// Optional age parameter
 	ageStr := c.Query("age")
-	age := 0
 	if ageStr != "" {
 		if parsedAge, err := strconv.Atoi(ageStr); err == nil && parsedAge > 0 {
-			age = parsedAge
+			wishReq.Age = parsedAge
 		}
 	}
+	b, ok := h.personalizeByName(restTenant(c), &wishReq)
 
-	// If Gemini is not available, return a nice fallback wish
-	if h.geminiClient == nil {
-		fallbackWish := fmt.Sprintf("🎉 Happy Birthday, %s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! May this year bring you endless blessings and amazing adventures! 🌟", name)
-		return c.Status(200).JSON(fiber.Map{
-			"name":   name,
-			"wish":   fallbackWish,
-			"source": "fallback",
-		})
-	}
-
-	var wish string
-	var err error
+	return h.sendWish(c, wishReq, fiber.Map{}, b, ok)
+}
 
-	if age > 0 {
-		wish, err = h.geminiClient.GenerateBirthdayWish(name, age)
-	} else {
-		wish, err = h.geminiClient.GenerateGenericBirthdayWish(name)
-		log.Println(err)
+// sendWish generates the wish and responds with it, adding to response. The
+// wish is recorded in the history of b when known is set.
+func (h *Handler) sendWish(c *fiber.Ctx, req clients.WishRequest, response fiber.Map, b store.Birthday, known bool) error {
+	wish := clients.WishOrFallback(c.Context(), h.wishes, req)
+	if known {
+		h.recordWish(b, wish)
 	}
-
-	if err != nil {
-		log.Printf("Error generating birthday wish: %v", err)
-		fallbackWish := fmt.Sprintf("🎉 Happy Birthday, %s! 🎂 Wishing you all the joy, happiness, and wonderful surprises on your special day! May this year bring you endless blessings and amazing adventures! 🌟", name)
-		return c.Status(200).JSON(fiber.Map{
-			"name":   name,
-			"wish":   fallbackWish,
-			"source": "fallback",
-		})
+	response["name"] = req.Name
+	response["wish"] = wish.Text
+	response["source"] = wish.Source
+	response["tone"] = req.Tone
+	if req.Language != "" {
+		response["language"] = req.Language
 	}
-
-	response := fiber.Map{
-		"name":   name,
-		"wish":   wish,
-		"source": "gemini",
-	}
-
-	if age > 0 {
-		response["age"] = age
+	if req.Age > 0 {
+		response["age"] = req.Age
 	}
-
 	return c.Status(200).JSON(response)
 }
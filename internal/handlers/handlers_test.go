@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"hazel_ai/internal/wishsession"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testServer is a Handler behind the A2A endpoint and the wish REST
+// endpoints, backed by a JSON store in a temp directory
+type testServer struct {
+	handler *Handler
+	store   store.Repository
+	app     *fiber.App
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	repo, err := store.NewBirthdayStore(filepath.Join(t.TempDir(), "birthdays.json"), store.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(repo, store.Calendar{}, dateparse.Parser{}, conversation.NewStore(time.Hour),
+		clients.TemplateWishes{}, wishsession.NewStore(time.Hour), nil, task.NewStore(time.Hour), nil)
+	app := fiber.New()
+	app.Post("/", h.HandleTelexA2A)
+	app.Get("/api/birthdays/:id/wishes", h.ListBirthdayWishes)
+	app.Post("/api/wishes/generate", h.GenerateBirthdayWish)
+	app.Post("/api/wishes/sessions", h.CreateWishSession)
+	app.Get("/api/wishes/sessions/:id", h.GetWishSession)
+	app.Post("/api/wishes/sessions/:id/regenerate", h.RegenerateWishSession)
+	return &testServer{handler: h, store: repo, app: app}
+}
+
+// call posts a JSON-RPC request to the A2A endpoint and decodes the response
+func (s *testServer) call(t *testing.T, method string, params any) (json.RawMessage, *protocol.Error) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, rpcErr, _ := s.post(t, string(body))
+	return result, rpcErr
+}
+
+// post sends a raw body to the A2A endpoint and returns the decoded response
+// along with its HTTP status
+func (s *testServer) post(t *testing.T, body string) (json.RawMessage, *protocol.Error, int) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Result json.RawMessage `json:"result"`
+		Error  *protocol.Error `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	return out.Result, out.Error, resp.StatusCode
+}
+
+// rest sends a request to a REST endpoint, decodes the JSON response into
+// out and returns the HTTP status
+func (s *testServer) rest(t *testing.T, method, path, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("decode %s: %v", data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// send sends a chat message in the given context and returns the finished task
+func (s *testServer) send(t *testing.T, contextID, text string) protocol.Task {
+	t.Helper()
+	message := protocol.NewMessage(protocol.RoleUser, text)
+	message.ContextID = contextID
+
+	result, rpcErr := s.call(t, protocol.MethodMessageSend, protocol.MessageSendParams{Message: message})
+	if rpcErr != nil {
+		t.Fatalf("message/send %q: %v", text, rpcErr)
+	}
+	var task protocol.Task
+	if err := json.Unmarshal(result, &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	return task
+}
+
+// chat sends a chat message and returns Hazel's reply text
+func (s *testServer) chat(t *testing.T, contextID, text string) string {
+	t.Helper()
+	task := s.send(t, contextID, text)
+	if task.Status.Message == nil {
+		t.Fatalf("task for %q has no reply", text)
+	}
+	return task.Status.Message.Text()
+}
+
+// testTenant is the birthday book of messages sent in the "ctx" context
+const testTenant = "context:ctx"
+
+func TestHandleTelexA2AErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		status int
+	}{
+		{"invalid JSON", `{"jsonrpc": "2.0", "id": 1, "method"`, protocol.CodeParseError, 400},
+		{"wrong version", `{"jsonrpc": "1.0", "id": 1, "method": "message/send"}`, protocol.CodeInvalidRequest, 400},
+		{"unknown top-level field", `{"jsonrpc": "2.0", "id": 1, "method": "tasks/get", "params": {"id": "t"}, "session": "x"}`, protocol.CodeInvalidRequest, 400},
+		{"unknown method", `{"jsonrpc": "2.0", "id": 1, "method": "tasks/resubscribe", "params": {"id": "t"}}`, protocol.CodeMethodNotFound, 400},
+		{"unknown part kind", `{"jsonrpc": "2.0", "id": 1, "method": "message/send", "params": {"message": {"role": "user", "parts": [{"kind": "image"}]}}}`, protocol.CodeInvalidParams, 400},
+		{"unknown task", `{"jsonrpc": "2.0", "id": 1, "method": "tasks/get", "params": {"id": "missing"}}`, protocol.CodeTaskNotFound, 404},
+	}
+
+	s := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rpcErr, status := s.post(t, tt.body)
+			if rpcErr == nil || rpcErr.Code != tt.code {
+				t.Fatalf("error = %v, want code %d", rpcErr, tt.code)
+			}
+			if status != tt.status {
+				t.Errorf("HTTP status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestTelexMessages(t *testing.T) {
+	s := newTestServer(t)
+	payload := func(name string) string {
+		data, err := os.ReadFile(filepath.Join("..", "a2a", "protocol", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Not blocking: the task comes back working and is polled until it ends
+	result, rpcErr, _ := s.post(t, payload("telex_message_send.json"))
+	if rpcErr != nil {
+		t.Fatalf("message/send: %v", rpcErr)
+	}
+	var started protocol.Task
+	if err := json.Unmarshal(result, &started); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for current := started; !protocol.Terminal(current.Status.State); {
+		if time.Now().After(deadline) {
+			t.Fatalf("task still %s", current.Status.State)
+		}
+		time.Sleep(10 * time.Millisecond)
+		result, rpcErr := s.call(t, protocol.MethodTasksGet, map[string]any{"id": started.ID})
+		if rpcErr != nil {
+			t.Fatal(rpcErr)
+		}
+		if err := json.Unmarshal(result, &current); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved, err := s.store.List("channel:01992f5c-7b3a-7c1e-a2f4-000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Name != "Alice" {
+		t.Fatalf("saved %+v, want Alice in the channel's book", saved)
+	}
+
+	result, rpcErr, _ = s.post(t, payload("telex_message_send_blocking.json"))
+	if rpcErr != nil {
+		t.Fatalf("message/send: %v", rpcErr)
+	}
+	var listed protocol.Task
+	if err := json.Unmarshal(result, &listed); err != nil {
+		t.Fatal(err)
+	}
+	if listed.Status.State != protocol.StateCompleted || !strings.Contains(listed.Status.Message.Text(), "Alice") {
+		t.Errorf("list task = %s %+v, want Alice listed", listed.Status.State, listed.Status.Message)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/importer"
+	"hazel_ai/internal/intent"
+	"hazel_ai/internal/store"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxListedImportErrors caps how many unreadable rows an import reply lists
+const maxListedImportErrors = 10
+
+// importResult is the machine-readable result of saving birthdays sent as
+// data or file parts
+type importResult struct {
+	Saved   []birthdayView `json:"saved"`
+	Skipped []importIssue  `json:"skipped"`
+	Failed  []importIssue  `json:"failed"`
+}
+
+// importIssue is a record that wasn't saved and why
+type importIssue struct {
+	importer.Record
+	Reason string `json:"reason"`
+}
+
+// structuredReply saves the birthdays in the message's data and file parts,
+// which need no understanding: a data part such as {"name": "Alice", "date":
+// "1990-04-12"} is one birthday and a CSV or vCard file is many. It reports
+// false when the message has no such parts, so its text is routed as usual.
+func (h *Handler) structuredReply(m intent.Message) (intent.Reply, bool) {
+	var records []importer.Record
+	var files []string
+	for _, part := range m.Params.Message.Parts {
+		switch part.Kind {
+		case protocol.PartData:
+			// Only objects naming someone are birthdays; Telex's history
+			// array and other data are left alone
+			object, ok := part.Data.(map[string]any)
+			if _, named := object["name"]; !ok || !named {
+				continue
+			}
+			record, err := dataRecord(object)
+			if err != nil {
+				return intent.Reply{Text: fmt.Sprintf("❌ I couldn't read that birthday: %v", err), Failed: true}, true
+			}
+			records = append(records, record)
+
+		case protocol.PartFile:
+			fileRecords, err := fileRecords(*part.File)
+			if err != nil {
+				return intent.Reply{Text: fmt.Sprintf("❌ I couldn't import %s: %v", fileLabel(*part.File), err), Failed: true}, true
+			}
+			records = append(records, fileRecords...)
+			files = append(files, fileLabel(*part.File))
+		}
+	}
+	if len(records) == 0 && len(files) == 0 {
+		return intent.Reply{}, false
+	}
+
+	result, err := h.importBirthdays(m.Tenant, records)
+	if err != nil {
+		log.Printf("Error importing birthdays: %v", err)
+		return intent.Reply{Text: "❌ Sorry, I couldn't load the birthdays right now. Please try again later.", Failed: true}, true
+	}
+	log.Printf("Imported %d birthdays (%d skipped, %d failed) for %s", len(result.Saved), len(result.Skipped), len(result.Failed), m.Tenant)
+
+	reply := intent.Reply{Text: importText(result, files), Data: fiber.Map{"saved": result.Saved, "skipped": result.Skipped, "failed": result.Failed}}
+	reply.Failed = len(result.Saved) == 0 && len(result.Failed) > 0
+	return reply, true
+}
+
+// dataRecord reads a birthday from a data part
+func dataRecord(data map[string]any) (importer.Record, error) {
+	var record importer.Record
+	encoded, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(encoded, &record)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return record, fmt.Errorf("%s has the wrong type", typeErr.Field)
+	}
+	record.Line = 0
+	return record, err
+}
+
+// fileRecords reads the birthdays in a CSV or vCard file part. Files must
+// be sent inline; Hazel doesn't fetch URIs.
+func fileRecords(file protocol.File) ([]importer.Record, error) {
+	if file.Bytes == "" {
+		return nil, errors.New("I can't fetch files from links, please attach the file itself")
+	}
+	data, err := file.Content()
+	if err != nil {
+		return nil, err
+	}
+	format, err := importer.Detect(file.Name, file.MimeType, data)
+	if err != nil {
+		return nil, err
+	}
+	return importer.Parse(format, data)
+}
+
+// fileLabel names a file part in replies
+func fileLabel(file protocol.File) string {
+	if file.Name != "" {
+		return file.Name
+	}
+	return "the file"
+}
+
+// importBirthdays saves the records in the tenant's book. Records without a
+// name or with a date that can't be read fail; people already saved on the
+// same day are skipped. The error is only set when the book can't be read.
+func (h *Handler) importBirthdays(tenant string, records []importer.Record) (importResult, error) {
+	result := importResult{Saved: []birthdayView{}, Skipped: []importIssue{}, Failed: []importIssue{}}
+
+	existing, err := h.birthdayStore.List(tenant)
+	if err != nil {
+		return result, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, b := range existing {
+		known[importKey(b)] = true
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		if record.Date == "" {
+			result.Failed = append(result.Failed, importIssue{Record: record, Reason: "date is required"})
+			continue
+		}
+		date, err := h.dates.Parse(record.Date)
+		if errors.Is(err, dateparse.ErrNoDate) {
+			err = fmt.Errorf("%q is not a date I understand", record.Date)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, importIssue{Record: record, Reason: err.Error()})
+			continue
+		}
+
+		if known[importKey(store.Birthday{Name: record.Name, Month: date.Month, Day: date.Day})] {
+			result.Skipped = append(result.Skipped, importIssue{Record: record, Reason: "already saved"})
+			continue
+		}
+
+		input := store.NewBirthday{Name: record.Name, Date: date.String(), HideYear: record.HideYear, Interests: record.Interests, Notes: record.Notes, Tenant: tenant}
+		id, err := h.birthdayStore.AddBirthday(input)
+		if err != nil {
+			result.Failed = append(result.Failed, importIssue{Record: record, Reason: err.Error()})
+			continue
+		}
+		b, err := h.birthdayStore.Get(tenant, id)
+		if err != nil {
+			return result, err
+		}
+		known[importKey(b)] = true
+		result.Saved = append(result.Saved, h.newBirthdayView(b, now))
+	}
+	return result, nil
+}
+
+// importKey identifies a person's birthday for spotting duplicates
+func importKey(b store.Birthday) string {
+	return fmt.Sprintf("%s|%02d-%02d", strings.ToLower(strings.Join(strings.Fields(b.Name), " ")), b.Month, b.Day)
+}
+
+// importText describes an import for the chat. A single birthday gets the
+// same answer as one typed in chat.
+func importText(result importResult, files []string) string {
+	if len(files) == 0 && len(result.Saved)+len(result.Skipped)+len(result.Failed) == 1 {
+		switch {
+		case len(result.Saved) == 1:
+			b := result.Saved[0]
+			return fmt.Sprintf("🎂 Perfect! I've remembered %s's birthday is on %s. 🎉", b.Name, birthdayDate(b.Birthday))
+		case len(result.Skipped) == 1:
+			return fmt.Sprintf("👍 I already have %s's birthday saved.", result.Skipped[0].Name)
+		default:
+			issue := result.Failed[0]
+			return fmt.Sprintf("❌ Sorry, I couldn't store %s's birthday. Error: %s", issue.Name, issue.Reason)
+		}
+	}
+
+	source := "your message"
+	if len(files) > 0 {
+		source = strings.Join(files, ", ")
+	}
+	if len(result.Saved)+len(result.Skipped)+len(result.Failed) == 0 {
+		return fmt.Sprintf("🤷 I didn't find any birthdays in %s.", source)
+	}
+
+	response := fmt.Sprintf("📥 Imported %d birthdays from %s.", len(result.Saved), source)
+	if len(result.Skipped) > 0 {
+		response += fmt.Sprintf("\n👍 Skipped %d already saved.", len(result.Skipped))
+	}
+	if len(result.Failed) > 0 {
+		response += fmt.Sprintf("\n❌ %d couldn't be saved:\n", len(result.Failed))
+		for i, issue := range result.Failed {
+			if i == maxListedImportErrors {
+				response += fmt.Sprintf("• …and %d more\n", len(result.Failed)-i)
+				break
+			}
+			response += "• " + issueText(issue) + "\n"
+		}
+	}
+	return response
+}
+
+// issueText describes a record that couldn't be saved
+func issueText(issue importIssue) string {
+	name := issue.Name
+	if name == "" {
+		name = "no name"
+	}
+	if issue.Line > 0 {
+		return fmt.Sprintf("line %d (%s): %s", issue.Line, name, issue.Reason)
+	}
+	return fmt.Sprintf("%s: %s", name, issue.Reason)
+}
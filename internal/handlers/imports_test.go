@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"hazel_ai/internal/a2a/protocol"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportFileReportsBadRows(t *testing.T) {
+	s := newTestServer(t)
+	data, err := os.ReadFile(filepath.Join("..", "importer", "testdata", "birthdays.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := protocol.NewMessage(protocol.RoleUser, "")
+	message.Parts = []protocol.Part{{Kind: protocol.PartFile, File: &protocol.File{
+		Name: "birthdays.csv", MimeType: "text/csv", Bytes: base64.StdEncoding.EncodeToString(data),
+	}}}
+	message.ContextID = "ctx"
+	result, rpcErr := s.call(t, protocol.MethodMessageSend, protocol.MessageSendParams{Message: message})
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+
+	reply := string(result)
+	for _, want := range []string{
+		"Imported 3 birthdays from birthdays.csv",
+		"line 7 (Carol): date is required",
+		"line 8 (no name)",
+		"line 9 (Dave)",
+	} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("reply doesn't mention %q:\n%s", want, reply)
+		}
+	}
+
+	saved, err := s.store.List(testTenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 {
+		t.Fatalf("saved %d birthdays, want 3: %+v", len(saved), saved)
+	}
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/agent"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/intent"
+	"math"
+	"strings"
+)
+
+// newMessage recognises the date and names in a chat message so every intent
+// scores and handles the same reading of it
+func (h *Handler) newMessage(params protocol.MessageSendParams) intent.Message {
+	params = h.scopeParams(params)
+	text := params.Message.Text()
+	m := intent.Message{
+		Text:            text,
+		Lower:           strings.ToLower(strings.TrimSpace(text)),
+		Params:          params,
+		Tenant:          a2aTenant(params),
+		ConversationKey: conversationKey(params),
+		Context:         context.Background(),
+	}
+
+	date, dateText, err := h.dates.Find(text)
+	var invalid *dateparse.InvalidDateError
+	switch {
+	case err == nil:
+		m.Date, m.DateText = &date, dateText
+	case errors.As(err, &invalid):
+		m.DateErr, m.DateText = err, invalid.Text
+	}
+
+	// Remove the date so month names aren't read as names
+	match := extractBirthdayName(m.TextWithoutDate())
+	m.Names, m.Self = match.Names, match.Self
+	return m
+}
+
+// newRouter registers everything Hazel can do in chat. The help text and the
+// agent card's skills are generated from these entries.
+func (h *Handler) newRouter() *intent.Router {
+	return intent.NewRouter(
+		intent.Func{
+			ID:      "remember",
+			Summary: "Remember birthdays",
+			Samples: []string{"Remember my birthday 2005-01-01", "Alice's birthday is March 3rd", "Bob was born on 1990-02-14"},
+			ScoreFunc: func(m intent.Message) float64 {
+				score := 0.0
+				if mentions(m, "remember", "save", "store", "add", "note down") {
+					score += 0.5
+				}
+				if mentions(m, "was born", "birthday is", "bday is", "my birthday", "don't forget", "do not forget") {
+					score += 0.5
+				}
+				// "remember to wish Alice" asks to keep track of Alice, whatever
+				// the verb after it
+				if mentions(m, reminderPhrases...) {
+					score += 0.5
+				}
+				if m.HasDate() {
+					score += 0.4
+				}
+				if len(m.Names) > 0 || m.Self {
+					score += 0.1
+				}
+				return math.Min(score, 1)
+			},
+			HandleFunc: h.handleRemember,
+		},
+		intent.Func{
+			ID:      "wish",
+			Summary: "Generate birthday wishes",
+			Samples: []string{"Generate a birthday wish", "Wish Alice a happy birthday", "Write a short funny wish for my manager Bob in Spanish"},
+			ScoreFunc: func(m intent.Message) float64 {
+				score := 0.0
+				switch {
+				case mentions(m, "wish", "wishes", "greeting", "congratulate", "congratulations", "congrats", "happy birthday"):
+					score = 0.6
+				case mentions(m, "generate", "random", "write"):
+					score = 0.4
+				default:
+					return 0
+				}
+				_, text := parseWishOptions(m.TextWithoutDate())
+				if name, _ := targetName(text, "wish", "congratulate", "greet", "to"); name != "" {
+					score += 0.2
+				}
+				// A date suggests the message is about saving a birthday instead
+				if m.HasDate() {
+					score -= 0.2
+				}
+				if mentions(m, reminderPhrases...) {
+					score -= 0.5
+				}
+				return score
+			},
+			HandleFunc: h.handleWish,
+		},
+		intent.Func{
+			ID:      "list",
+			Summary: "List stored birthdays",
+			Samples: []string{"List birthdays", "Show all birthdays"},
+			ScoreFunc: func(m intent.Message) float64 {
+				score := 0.0
+				switch {
+				case mentions(m, "list", "show", "all") && mentions(m, "birthdays", "bdays"):
+					score = 0.7
+				case mentions(m, "list"):
+					score = 0.6
+				}
+				if score > 0 && mentions(m, upcomingPhrases...) {
+					score -= 0.3
+				}
+				return score
+			},
+			HandleFunc: h.handleList,
+		},
+		intent.Func{
+			ID:      "upcoming",
+			Summary: "Show upcoming birthdays",
+			Samples: []string{"Upcoming birthdays", "Who has a birthday coming up?"},
+			ScoreFunc: func(m intent.Message) float64 {
+				if mentions(m, upcomingPhrases...) {
+					return 0.8
+				}
+				return 0
+			},
+			HandleFunc: h.handleUpcoming,
+		},
+		intent.Func{
+			ID:      "when",
+			Summary: "Look up someone's birthday",
+			Samples: []string{"When is Alice's birthday?"},
+			ScoreFunc: func(m intent.Message) float64 {
+				if !mentions(m, "when is", "when's", "when was", "what day is", "what date is") {
+					return 0
+				}
+				if len(m.Names) > 0 || m.Self {
+					return 0.9
+				}
+				return 0.7
+			},
+			HandleFunc: h.handleWhen,
+		},
+		intent.Func{
+			ID:      "age",
+			Summary: "Say how old someone is turning",
+			Samples: []string{"How old is Bob turning?"},
+			ScoreFunc: func(m intent.Message) float64 {
+				if mentions(m, "how old") {
+					return 0.9
+				}
+				return 0
+			},
+			HandleFunc: h.handleAge,
+		},
+		intent.Func{
+			ID:      "change",
+			Summary: "Change a saved birthday",
+			Samples: []string{"Change Bob's birthday to March 3"},
+			ScoreFunc: func(m intent.Message) float64 {
+				if !mentions(m, "change", "update", "move", "correct", "fix") {
+					return 0
+				}
+				score := 0.5
+				if m.HasDate() {
+					score += 0.2
+				}
+				if mentions(m, "birthday", "bday") {
+					score += 0.1
+				}
+				if len(m.Names) > 0 || m.Self {
+					score += 0.1
+				}
+				return score
+			},
+			HandleFunc: h.handleChange,
+		},
+		intent.Func{
+			ID:      "forget",
+			Summary: "Forget a birthday",
+			Samples: []string{"Forget Alice's birthday"},
+			ScoreFunc: func(m intent.Message) float64 {
+				// "don't forget" asks Hazel to remember
+				if !mentions(m, "forget", "delete", "remove") || mentions(m, "don't forget", "do not forget", "never forget") {
+					return 0
+				}
+				if len(m.Names) > 0 || m.Self {
+					return 0.9
+				}
+				return 0.8
+			},
+			HandleFunc: h.handleForget,
+		},
+		intent.Func{
+			ID:      "help",
+			Summary: "Explain what Hazel can do",
+			Samples: []string{"Help", "What can you do?"},
+			ScoreFunc: func(m intent.Message) float64 {
+				if mentions(m, "help", "what can you do", "commands") {
+					return 0.9
+				}
+				if matchesPhrase(m.Lower, greetingPhrases) {
+					return 0.5
+				}
+				return 0
+			},
+			HandleFunc: func(m intent.Message) intent.Reply {
+				return intent.Reply{Text: h.helpText()}
+			},
+		},
+	)
+}
+
+// reminderPhrases ask Hazel to keep something in mind for later, so the
+// verb that follows them isn't the request
+var reminderPhrases = []string{"remember to", "don't forget to", "do not forget to", "never forget to", "remind me to"}
+
+// upcomingPhrases ask about the next birthdays rather than all of them
+var upcomingPhrases = []string{"upcoming", "coming up", "next birthday", "next birthdays", "soon", "this week", "this month"}
+
+// greetingPhrases are whole messages that just say hello
+var greetingPhrases = []string{"hi", "hello", "hey", "hi hazel", "hello hazel", "hey hazel"}
+
+// mentions reports whether the message contains any of the phrases as whole
+// words, so "list" doesn't match "listen"
+func mentions(m intent.Message, phrases ...string) bool {
+	text := " " + strings.Join(nameTokens(m.Lower), " ") + " "
+	for _, phrase := range phrases {
+		if strings.Contains(text, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// helpText lists what Hazel can do, with an example of each
+func (h *Handler) helpText() string {
+	response := "Hello! I'm Hazel, your birthday bot. I can help you with:\n"
+	for _, i := range h.router.Intents() {
+		if i.Name() == "help" {
+			continue
+		}
+		response += fmt.Sprintf("• %s (try '%s')\n", i.Description(), i.Examples()[0])
+	}
+	return response
+}
+
+// clarifyText asks which of several equally likely intents was meant
+func (h *Handler) clarifyText(candidates []intent.Match) string {
+	response := "🤔 I'm not sure what you mean. Did you want to:\n"
+	for _, c := range candidates {
+		response += fmt.Sprintf("• %s (like '%s')\n", c.Intent.Description(), c.Intent.Examples()[0])
+	}
+	return response + "\nTry rephrasing like one of the examples."
+}
+
+// agentSkills describes every registered intent as an agent card skill
+func (h *Handler) agentSkills() []protocol.Skill {
+	var skills []protocol.Skill
+	for _, i := range h.router.Intents() {
+		skill := protocol.Skill{
+			ID:          i.Name(),
+			Name:        i.Description(),
+			Description: fmt.Sprintf("%s, e.g. '%s'", i.Description(), i.Examples()[0]),
+			InputModes:  []string{"text/plain"},
+			OutputModes: []string{"text/plain"},
+		}
+		for _, example := range i.Examples() {
+			skill.Examples = append(skill.Examples, protocol.SkillExample{
+				Input: protocol.ExampleMessage{Parts: []protocol.ExamplePart{{Text: example, ContentType: "text/plain"}}},
+			})
+		}
+		skills = append(skills, skill)
+	}
+	return skills
+}
+
+// agentCard loads the agent card and fills in the skills and capabilities
+// from the code, so the card can't promise more than the code does
+func (h *Handler) agentCard() (protocol.AgentCard, error) {
+	var card protocol.AgentCard
+	data, err := agent.LoadDefaultAgentCard()
+	if err != nil {
+		return card, err
+	}
+	if err := json.Unmarshal(data, &card); err != nil {
+		return card, fmt.Errorf("failed to parse agent card: %w", err)
+	}
+
+	card.Skills = h.agentSkills()
+	card.Capabilities = protocol.Capabilities{
+		Streaming:              true,
+		PushNotifications:      h.pushConfigs != nil,
+		StateTransitionHistory: true,
+	}
+	return card, nil
+}
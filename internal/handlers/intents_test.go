@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"hazel_ai/internal/a2a/protocol"
+	"strings"
+	"testing"
+)
+
+// routeText routes a chat message and returns the chosen intent's name, or
+// "" when none was chosen
+func routeText(h *Handler, text string) string {
+	message := protocol.NewMessage(protocol.RoleUser, text)
+	message.ContextID = "ctx"
+	m := h.newMessage(protocol.MessageSendParams{Message: message})
+	if best := h.router.Route(m).Best; best != nil {
+		return best.Name()
+	}
+	return ""
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		// "remember to …" is about keeping track of someone, not the verb
+		{"remember to wish Alice", "remember"},
+		{"Remember to wish Alice a happy birthday", "remember"},
+		{"don't forget to wish Bob", "remember"},
+		{"do not forget to congratulate Carol", "remember"},
+		{"remind me to wish Dan", "remember"},
+		{"don't forget Alice's birthday is May 5", "remember"},
+		{"Alice was born on 1990-04-12", "remember"},
+
+		{"wish Alice", "wish"},
+		{"generate a random wish", "wish"},
+		{"write a funny birthday wish for Bob", "wish"},
+		{"congratulate Carol", "wish"},
+
+		{"list all birthdays", "list"},
+		{"show upcoming birthdays", "upcoming"},
+		{"who has a birthday this week?", "upcoming"},
+		{"when's Bob's birthday", "when"},
+		{"how old is Alice", "age"},
+		{"update Bob's birthday to June 1", "change"},
+		{"delete Alice", "forget"},
+		{"what can you do?", "help"},
+		{"hi", "help"},
+
+		{"", ""},
+		{"the weather is nice", ""},
+	}
+
+	h := newTestServer(t).handler
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := routeText(h, tt.text); got != tt.want {
+				t.Errorf("routed %q to %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// Every example shown in the help text and agent card must reach its intent
+func TestRouterExamples(t *testing.T) {
+	h := newTestServer(t).handler
+	for _, i := range h.router.Intents() {
+		for _, example := range i.Examples() {
+			if got := routeText(h, example); got != i.Name() {
+				t.Errorf("example %q of %s routed to %q", example, i.Name(), got)
+			}
+		}
+	}
+}
+
+func TestRememberToWish(t *testing.T) {
+	for _, text := range []string{"remember to wish Alice", "Remember to wish Alice a happy birthday", "don't forget to congratulate Alice"} {
+		t.Run(text, func(t *testing.T) {
+			s := newTestServer(t)
+			if reply := s.chat(t, "ctx", text); !strings.Contains(reply, "When is Alice's birthday") {
+				t.Fatalf("reply = %q, want Hazel to ask for Alice's birthday", reply)
+			}
+			if reply := s.chat(t, "ctx", "March 3"); !strings.Contains(reply, "Alice's birthday is on March 3") {
+				t.Errorf("reply = %q, want Alice saved", reply)
+			}
+		})
+	}
+}
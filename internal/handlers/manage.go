@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/intent"
+	"hazel_ai/internal/store"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Replies that confirm or refuse a destructive change
+var (
+	yesPhrases  = []string{"yes", "y", "yeah", "yep", "sure", "ok", "okay", "confirm", "do it", "yes please"}
+	noPhrases   = []string{"no", "n", "nope", "don't", "dont", "keep it", "no thanks"}
+	undoPhrases = []string{"undo", "undo that", "undo it", "bring it back", "put it back", "change it back"}
+)
+
+// handleForget processes "forget Alice's birthday"
+func (h *Handler) handleForget(m intent.Message) intent.Reply {
+	return h.advanceManage(m, h.manageSlots(conversation.IntentForget, m, m.Text, "forget", "delete", "remove"))
+}
+
+// handleChange processes "change Bob's birthday to March 3"
+func (h *Handler) handleChange(m intent.Message) intent.Reply {
+	var invalid *dateparse.InvalidDateError
+	if errors.As(m.DateErr, &invalid) {
+		response := fmt.Sprintf("❌ %s isn't a real date: %s. Could you check it and try again?", invalid.Text, invalid.Reason)
+		return intent.Reply{Text: response}
+	}
+
+	state := h.manageSlots(conversation.IntentChange, m, m.TextWithoutDate(), "change", "update", "move")
+	if m.Date != nil {
+		fillDate(&state, *m.Date)
+	}
+	return h.advanceManage(m, state)
+}
+
+// handleWhen processes "when is Carol's birthday?"
+func (h *Handler) handleWhen(m intent.Message) intent.Reply {
+	return h.advanceManage(m, h.manageSlots(conversation.IntentWhen, m, m.Text, "is", "was"))
+}
+
+// handleAge processes "how old is Dan turning?"
+func (h *Handler) handleAge(m intent.Message) intent.Reply {
+	return h.advanceManage(m, h.manageSlots(conversation.IntentAge, m, m.Text, "is", "will", "am"))
+}
+
+// manageSlots starts the state for a command about an existing birthday,
+// reading the name from text
+func (h *Handler) manageSlots(name string, m intent.Message, text string, verbs ...string) conversation.State {
+	state := conversation.State{Intent: name, Slots: map[string]string{}}
+	person, self := targetName(text, verbs...)
+	if self {
+		person = senderDisplayName(m.Params)
+		state.Slots[conversation.SlotSelf] = "true"
+	}
+	state.Slots[conversation.SlotName] = person
+	return state
+}
+
+// advanceManage works through a command about an existing birthday: find the
+// entry (asking which one if several share the name), collect a new date
+// for changes, confirm destructive actions, then carry it out
+func (h *Handler) advanceManage(m intent.Message, state conversation.State) intent.Reply {
+	tenant, key := m.Tenant, m.ConversationKey
+	name := state.Slots[conversation.SlotName]
+
+	if !state.Has(conversation.SlotID) {
+		if name == "" {
+			state.Awaiting = conversation.SlotName
+			h.conversations.Put(key, state)
+			if state.Has(conversation.SlotSelf) {
+				return intent.Reply{Text: "🤔 What name is your birthday saved under?"}
+			}
+			return intent.Reply{Text: "🤔 Whose birthday do you mean?"}
+		}
+
+		matches, err := store.FindByName(h.birthdayStore, tenant, name)
+		if err != nil {
+			h.conversations.Clear(key)
+			log.Printf("Error finding birthdays for %s: %v", name, err)
+			return intent.Reply{Text: "❌ Sorry, I couldn't load the birthdays right now. Please try again later.", Failed: true}
+		}
+
+		switch len(matches) {
+		case 0:
+			h.conversations.Clear(key)
+			response := fmt.Sprintf("🤷 I don't have a birthday saved for %s.", name)
+			return intent.Reply{Text: response}
+		case 1:
+			state.Slots[conversation.SlotID] = matches[0].ID
+		default:
+			ids := make([]string, len(matches))
+			response := fmt.Sprintf("🤔 I know %d people called %s. Which one do you mean?\n\n", len(matches), name)
+			for i, b := range matches {
+				ids[i] = b.ID
+				response += fmt.Sprintf("%d. %s - %s\n", i+1, b.Name, birthdayDate(b))
+			}
+			response += "\nReply with the number."
+			state.Slots[conversation.SlotCandidates] = strings.Join(ids, ",")
+			state.Awaiting = conversation.SlotCandidates
+			h.conversations.Put(key, state)
+			return intent.Reply{Text: response}
+		}
+	}
+
+	b, err := h.birthdayStore.Get(tenant, state.Slots[conversation.SlotID])
+	if err != nil {
+		h.conversations.Clear(key)
+		return intent.Reply{Text: "🤷 That birthday isn't saved anymore."}
+	}
+
+	now := time.Now()
+	switch state.Intent {
+	case conversation.IntentWhen:
+		h.conversations.Clear(key)
+		response := fmt.Sprintf("🎂 %s's birthday is on %s%s, %s.", b.Name, birthdayDate(b), h.turningSuffix(b, now), h.whenText(b, now))
+		return intent.Reply{Text: response}
+
+	case conversation.IntentAge:
+		h.conversations.Clear(key)
+		if b.HideYear {
+			return intent.Reply{Text: fmt.Sprintf("🤫 %s's age is private.", b.Name)}
+		}
+		turning, ok := h.calendar.TurningOn(b, now)
+		if !ok {
+			// Ask for the year so the question can be answered next time
+			state = conversation.State{Intent: conversation.IntentAge, Slots: map[string]string{
+				conversation.SlotID:   b.ID,
+				conversation.SlotDate: fmt.Sprintf("%02d-%02d", b.Month, b.Day),
+			}, Awaiting: conversation.SlotYear}
+			h.conversations.Put(key, state)
+			response := fmt.Sprintf("🤔 I don't know what year %s was born. What year was it? (or say 'skip')", b.Name)
+			return intent.Reply{Text: response}
+		}
+		if h.calendar.DaysUntil(b, now) == 0 {
+			return intent.Reply{Text: fmt.Sprintf("🎉 %s turns %d today!", b.Name, turning)}
+		}
+		response := fmt.Sprintf("🎈 %s is turning %d on %s, %s.", b.Name, turning, birthdayDate(b), h.whenText(b, now))
+		return intent.Reply{Text: response}
+
+	case conversation.IntentChange:
+		if !state.Has(conversation.SlotDate) {
+			state.Awaiting = conversation.SlotDate
+			h.conversations.Put(key, state)
+			response := fmt.Sprintf("📅 What should %s's birthday be changed to?", b.Name)
+			return intent.Reply{Text: response}
+		}
+	}
+
+	if !state.Has(conversation.SlotConfirm) {
+		if key == "" {
+			return intent.Reply{Text: "⚠️ I need to ask you to confirm that, but I can't follow up in this chat. Please try again from a Telex conversation."}
+		}
+		state.Awaiting = conversation.SlotConfirm
+		h.conversations.Put(key, state)
+
+		response := fmt.Sprintf("🗑️ Forget %s's birthday (%s)? Reply yes or no.", b.Name, birthdayDate(b))
+		if state.Intent == conversation.IntentChange {
+			response = fmt.Sprintf("✏️ Change %s's birthday from %s to %s? Reply yes or no.", b.Name, birthdayDate(b), formatSlotDate(state))
+		}
+		return intent.Reply{Text: response}
+	}
+
+	before := b
+	var response string
+	switch state.Intent {
+	case conversation.IntentForget:
+		if err := h.birthdayStore.Delete(tenant, b.ID); err != nil {
+			h.conversations.Clear(key)
+			return intent.Reply{Text: fmt.Sprintf("❌ Sorry, I couldn't forget %s's birthday. Error: %s", b.Name, err.Error()), Failed: true}
+		}
+		response = fmt.Sprintf("🗑️ Forgot %s's birthday. Say 'undo' to bring it back.", b.Name)
+
+	case conversation.IntentChange:
+		// Keep the known birth year unless a new one was given
+		date := state.Slots[conversation.SlotDate]
+		if state.Has(conversation.SlotYear) {
+			date = state.Slots[conversation.SlotYear] + "-" + date
+		} else if b.Year > 0 {
+			date = strconv.Itoa(b.Year) + "-" + date
+		}
+
+		updated, err := h.birthdayStore.Patch(tenant, b.ID, store.BirthdayPatch{Date: &date})
+		if err != nil {
+			h.conversations.Clear(key)
+			return intent.Reply{Text: fmt.Sprintf("❌ Sorry, I couldn't change %s's birthday. Error: %s", b.Name, err.Error()), Failed: true}
+		}
+		response = fmt.Sprintf("✏️ %s's birthday is now %s. Say 'undo' to change it back.", updated.Name, birthdayDate(updated))
+	}
+
+	log.Printf("%s birthday %s (%s)", state.Intent, b.ID, b.Name)
+	h.conversations.Put(key, conversation.State{Intent: state.Intent, Awaiting: conversation.SlotUndo, Undo: &before})
+	return intent.Reply{Text: response}
+}
+
+// answerManage handles replies to the questions advanceManage asks. It
+// reports false when the message isn't an answer.
+func (h *Handler) answerManage(m intent.Message, state conversation.State) (intent.Reply, bool) {
+	key, lower := m.ConversationKey, m.Lower
+
+	switch state.Awaiting {
+	case conversation.SlotCandidates:
+		ids := strings.Split(state.Slots[conversation.SlotCandidates], ",")
+		n, err := strconv.Atoi(strings.Trim(lower, " .#)"))
+		if err != nil || n < 1 || n > len(ids) {
+			return intent.Reply{}, false
+		}
+		state.Slots[conversation.SlotID] = ids[n-1]
+		return h.advanceManage(m, state), true
+
+	case conversation.SlotConfirm:
+		switch {
+		case matchesPhrase(lower, yesPhrases):
+			state.Slots[conversation.SlotConfirm] = "yes"
+			return h.advanceManage(m, state), true
+		case matchesPhrase(lower, noPhrases):
+			h.conversations.Clear(key)
+			return intent.Reply{Text: "👍 Okay, I've left it as it was."}, true
+		}
+
+	case conversation.SlotUndo:
+		if !matchesPhrase(lower, undoPhrases) || state.Undo == nil {
+			return intent.Reply{}, false
+		}
+		h.conversations.Clear(key)
+		b := *state.Undo
+		if err := h.birthdayStore.Restore(b); err != nil {
+			return intent.Reply{Text: fmt.Sprintf("❌ Sorry, I couldn't undo that. Error: %s", err.Error()), Failed: true}, true
+		}
+		log.Printf("Undid %s of birthday %s (%s)", state.Intent, b.ID, b.Name)
+		response := fmt.Sprintf("↩️ Brought back %s's birthday (%s).", b.Name, birthdayDate(b))
+		if state.Intent == conversation.IntentChange {
+			response = fmt.Sprintf("↩️ Changed %s's birthday back to %s.", b.Name, birthdayDate(b))
+		}
+		return intent.Reply{Text: response}, true
+	}
+	return intent.Reply{}, false
+}
+
+// whenText says how far away b's next birthday is
+func (h *Handler) whenText(b store.Birthday, now time.Time) string {
+	switch days := h.calendar.DaysUntil(b, now); days {
+	case 0:
+		return "that's today! 🎉"
+	case 1:
+		return "that's tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
+// birthdayDate shows b's date as "March 3"
+func birthdayDate(b store.Birthday) string {
+	return fmt.Sprintf("%s %d", time.Month(b.Month), b.Day)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"hazel_ai/internal/a2a/protocol"
+	"strings"
+	"unicode"
+)
+
+// Metadata keys that may carry the sender's display name, in order of preference
+var displayNameMetadataKeys = []string{"telex_user_name", "display_name", "displayName", "sender_name", "user_name", "username"}
+
+// nameWords are never part of a person's name in a chat command
+var nameWords = map[string]bool{
+	"remember": true, "please": true, "hazel": true, "hey": true, "hi": true, "can": true,
+	"could": true, "you": true, "that": true, "to": true, "save": true, "store": true,
+	"add": true, "note": true, "set": true, "also": true, "and": true, "the": true,
+	"a": true, "birthday": true, "bday": true, "is": true, "was": true, "on": true,
+	"born": true, "for": true, "of": true, "date": true, "in": true, "at": true,
+	"i": true, "me": true, "myself": true, "my": true, "his": true, "her": true,
+	"their": true, "our": true, "your": true, "its": true, "about": true,
+	"he": true, "she": true, "they": true, "him": true, "them": true, "it": true,
+	"forget": true, "delete": true, "remove": true, "change": true, "update": true,
+	"move": true, "when": true, "how": true, "old": true, "turning": true, "what": true,
+	"wish": true, "happy": true, "an": true, "random": true, "generate": true, "send": true,
+	"list": true, "show": true, "all": true, "birthdays": true, "bdays": true, "upcoming": true,
+	"next": true, "help": true, "commands": true, "today": true, "tomorrow": true,
+}
+
+// selfWords refer to the sender in "my birthday", "for me" and "I was born"
+var selfWords = map[string]bool{"my": true, "me": true, "myself": true, "i": true}
+
+// maxNameWords caps how many words are taken as a name
+const maxNameWords = 3
+
+// nameMatch is what extractBirthdayName found in a remember command
+type nameMatch struct {
+	// Names are the distinct people mentioned; more than one is ambiguous
+	Names []string
+	// Self is set when the sender talked about their own birthday
+	Self bool
+}
+
+// extractBirthdayName finds whose birthday a remember command is about. It
+// understands "Alice's birthday", "birthday for Alice" and "Alice was born on".
+func extractBirthdayName(text string) nameMatch {
+	words := nameTokens(text)
+	var match nameMatch
+
+	add := func(name string) {
+		if name == "" {
+			return
+		}
+		for _, existing := range match.Names {
+			if strings.EqualFold(existing, name) {
+				return
+			}
+		}
+		match.Names = append(match.Names, name)
+	}
+
+	for i, word := range words {
+		lower := strings.ToLower(word)
+
+		switch {
+		// "Alice's birthday", "Mary Jane's bday"
+		case strings.HasSuffix(lower, "'s") && i+1 < len(words) && isBirthdayWord(words[i+1]):
+			owner := strings.TrimSuffix(word[:len(word)-2], "'")
+			if selfWords[strings.ToLower(owner)] {
+				match.Self = true
+				continue
+			}
+			add(nameBefore(append(words[:i:i], owner)))
+
+		case lower == "my" && i+1 < len(words) && isBirthdayWord(words[i+1]):
+			match.Self = true
+
+		// "birthday for Alice", "remember for me"
+		case lower == "for" && i+1 < len(words):
+			if selfWords[strings.ToLower(words[i+1])] {
+				match.Self = true
+				continue
+			}
+			add(nameAfter(words[i+1:]))
+
+		// "Alice was born on", "I was born"
+		case lower == "was" && i > 0 && i+1 < len(words) && strings.EqualFold(words[i+1], "born"):
+			if selfWords[strings.ToLower(words[i-1])] {
+				match.Self = true
+				continue
+			}
+			add(nameBefore(words[:i]))
+		}
+	}
+
+	return match
+}
+
+// targetName finds who a command such as "forget Alice" or "how old is Dan
+// turning" is about: a possessive or "for" phrase, else the name after the
+// first of the given verbs. The second result is set for "me" or "my".
+func targetName(text string, verbs ...string) (string, bool) {
+	match := extractBirthdayName(text)
+	if len(match.Names) > 0 {
+		return match.Names[0], false
+	}
+	if match.Self {
+		return "", true
+	}
+
+	words := nameTokens(text)
+	for i, word := range words {
+		for _, verb := range verbs {
+			if !strings.EqualFold(word, verb) {
+				continue
+			}
+			rest := words[i+1:]
+			for len(rest) > 0 && (nameWords[strings.ToLower(rest[0])] || selfWords[strings.ToLower(rest[0])]) {
+				if selfWords[strings.ToLower(rest[0])] {
+					return "", true
+				}
+				rest = rest[1:]
+			}
+			if name := nameAfter(rest); name != "" {
+				return name, false
+			}
+		}
+	}
+	return "", false
+}
+
+// nameTokens splits text into words, keeping apostrophes and hyphens inside
+// words and normalising curly apostrophes
+func nameTokens(text string) []string {
+	text = strings.ReplaceAll(text, "’", "'")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+}
+
+func isBirthdayWord(word string) bool {
+	word = strings.ToLower(word)
+	return word == "birthday" || word == "bday"
+}
+
+// isNameWord reports whether word can be part of a name
+func isNameWord(word string) bool {
+	if word == "" || nameWords[strings.ToLower(word)] || !unicode.IsLetter([]rune(word)[0]) {
+		return false
+	}
+	return !strings.ContainsFunc(word, unicode.IsDigit)
+}
+
+func isCapitalized(word string) bool {
+	return word != "" && unicode.IsUpper([]rune(word)[0])
+}
+
+// nameBefore reads a name backwards from the end of words. Earlier words are
+// only included while they are capitalized, so "remember Mary Jane" gives
+// "Mary Jane" but "remember alice" gives "alice".
+func nameBefore(words []string) string {
+	var name []string
+	for i := len(words) - 1; i >= 0 && len(name) < maxNameWords; i-- {
+		word := words[i]
+		if !isNameWord(word) || (len(name) > 0 && !(isCapitalized(word) && isCapitalized(name[0]))) {
+			break
+		}
+		name = append([]string{word}, name...)
+	}
+	return formatName(name)
+}
+
+// nameAfter reads a name forwards from the start of words, following the
+// same capitalization rule as nameBefore
+func nameAfter(words []string) string {
+	var name []string
+	for _, word := range words {
+		if len(name) == maxNameWords || !isNameWord(word) || (len(name) > 0 && !(isCapitalized(word) && isCapitalized(name[0]))) {
+			break
+		}
+		name = append(name, word)
+	}
+	return formatName(name)
+}
+
+// formatName joins name words, capitalizing any the user typed in lowercase
+func formatName(words []string) string {
+	for i, word := range words {
+		if !isCapitalized(word) {
+			r := []rune(word)
+			words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// senderDisplayName returns the sender's display name from the A2A message
+// metadata, or "" when the request doesn't carry one
+func senderDisplayName(params protocol.MessageSendParams) string {
+	return strings.TrimSpace(lookupString(requestMetadata(params), displayNameMetadataKeys))
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	a2alogic "hazel_ai/internal/a2a"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"log"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetPushNotifications lets A2A clients register push notification configs,
+// kept in configs and delivered by sender. Push notifications are off while
+// configs is nil.
+func (h *Handler) SetPushNotifications(configs *store.PushConfigs, sender *a2alogic.PushSender) {
+	h.pushConfigs = configs
+	h.pusher = sender
+}
+
+// pushTask sends the task's latest state to every URL registered for it
+func (h *Handler) pushTask(t protocol.Task) {
+	if h.pushConfigs == nil {
+		return
+	}
+	for _, config := range h.pushConfigs.List(t.ID) {
+		go func() {
+			if err := h.pusher.Send(context.Background(), config, t); err != nil {
+				log.Printf("Failed to push task %s update: %v", t.ID, err)
+			}
+		}()
+	}
+}
+
+// handlePushConfigSet processes tasks/pushNotificationConfig/set A2A
+// requests. The config also subscribes the task's birthday book to the
+// daily reminders.
+func (h *Handler) handlePushConfigSet(c *fiber.Ctx, req protocol.Request) error {
+	if h.pushConfigs == nil {
+		return h.rpcError(c, req, protocol.PushNotSupported())
+	}
+	var params protocol.TaskPushNotificationConfig
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+	config, rpcErr := h.newPushConfig(params.PushNotificationConfig)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	taskID := params.TaskID
+	t, err := h.tasks.Get(taskID, 1)
+	if err != nil {
+		return h.rpcError(c, req, taskError(taskID, err))
+	}
+	if config.Tenant, err = h.tasks.Tenant(taskID); err != nil {
+		return h.rpcError(c, req, taskError(taskID, err))
+	}
+	config.TaskID, config.ContextID = taskID, t.ContextID
+
+	config, err = h.pushConfigs.Set(config)
+	if err != nil {
+		log.Printf("Error saving push notification config: %v", err)
+		return h.rpcError(c, req, protocol.InternalError("failed to save the push notification config"))
+	}
+	log.Printf("Push notifications for task %s (%s) will go to %s", taskID, config.Tenant, config.URL)
+	return h.sendResult(c, req, pushConfigResult(config))
+}
+
+// handlePushConfigGet processes tasks/pushNotificationConfig/get A2A
+// requests. Without a pushNotificationConfigId the task's newest config is
+// returned.
+func (h *Handler) handlePushConfigGet(c *fiber.Ctx, req protocol.Request) error {
+	params, rpcErr := h.pushConfigParams(req)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	if rpcErr := h.checkPushConfigTenant(params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	config, err := h.pushConfigs.Get(params.ID, params.PushNotificationConfigID)
+	if err != nil {
+		return h.rpcError(c, req, pushConfigError(params.ID, err))
+	}
+	return h.sendResult(c, req, pushConfigResult(config))
+}
+
+// handlePushConfigList processes tasks/pushNotificationConfig/list A2A requests
+func (h *Handler) handlePushConfigList(c *fiber.Ctx, req protocol.Request) error {
+	params, rpcErr := h.pushConfigParams(req)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	if rpcErr := h.checkPushConfigTenant(params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	configs := h.pushConfigs.List(params.ID)
+	results := make([]protocol.TaskPushNotificationConfig, 0, len(configs))
+	for _, config := range configs {
+		results = append(results, pushConfigResult(config))
+	}
+	return h.sendResult(c, req, results)
+}
+
+// handlePushConfigDelete processes tasks/pushNotificationConfig/delete A2A
+// requests. Deleting a task's config also ends the reminders it subscribed to.
+func (h *Handler) handlePushConfigDelete(c *fiber.Ctx, req protocol.Request) error {
+	params, rpcErr := h.pushConfigParams(req)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+	if params.PushNotificationConfigID == "" {
+		return h.rpcError(c, req, protocol.InvalidParams("pushNotificationConfigId is required"))
+	}
+	if rpcErr := h.checkPushConfigTenant(params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	if err := h.pushConfigs.Delete(params.ID, params.PushNotificationConfigID); err != nil {
+		return h.rpcError(c, req, pushConfigError(params.ID, err))
+	}
+	log.Printf("Deleted push notification config %s of task %s", params.PushNotificationConfigID, params.ID)
+	return h.sendResult(c, req, nil)
+}
+
+// newPushConfig checks a push notification config sent by a client and
+// converts it for the store. The caller fills in the task it belongs to.
+func (h *Handler) newPushConfig(pushConfig protocol.PushNotificationConfig) (store.PushConfig, *protocol.Error) {
+	if h.pushConfigs == nil {
+		return store.PushConfig{}, protocol.PushNotSupported()
+	}
+
+	config := store.PushConfig{ID: pushConfig.ID, URL: pushConfig.URL, Token: pushConfig.Token}
+	if u, err := url.Parse(config.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return config, protocol.InvalidParams("pushNotificationConfig.url must be an http or https URL")
+	}
+	if h.pusher == nil || !h.pusher.AllowPrivateHosts {
+		if err := a2alogic.CheckPushURL(config.URL); err != nil {
+			return config, protocol.InvalidParams("pushNotificationConfig.url: " + err.Error())
+		}
+	}
+	if auth := pushConfig.Authentication; auth != nil {
+		for _, scheme := range auth.Schemes {
+			if !a2alogic.SupportedAuthScheme(scheme) {
+				return config, protocol.InvalidParams(fmt.Sprintf("unsupported authentication scheme %q, only Bearer is supported", scheme))
+			}
+		}
+		config.AuthSchemes, config.Credentials = auth.Schemes, auth.Credentials
+	}
+	return config, nil
+}
+
+// pushConfigParams reads the params of a get, list or delete request
+func (h *Handler) pushConfigParams(req protocol.Request) (protocol.PushNotificationConfigParams, *protocol.Error) {
+	var params protocol.PushNotificationConfigParams
+	if h.pushConfigs == nil {
+		return params, protocol.PushNotSupported()
+	}
+	rpcErr := req.DecodeParams(&params)
+	return params, rpcErr
+}
+
+// checkPushConfigTenant makes sure the caller of a get, list or delete
+// request is in the task's birthday book. A task in a channel's or org's
+// book needs the same channel or org in the request metadata; one in a
+// context's book is reached through its ID, like with tasks/get. Configs
+// outlive their task, so once it is gone their own tenant is used.
+func (h *Handler) checkPushConfigTenant(params protocol.PushNotificationConfigParams) *protocol.Error {
+	tenant, contextID := "", ""
+	if t, err := h.tasks.Get(params.ID, 0); err == nil {
+		tenant, _ = h.tasks.Tenant(params.ID)
+		contextID = t.ContextID
+	} else if configs := h.pushConfigs.List(params.ID); len(configs) > 0 {
+		tenant, contextID = configs[0].Tenant, configs[0].ContextID
+	} else {
+		return taskError(params.ID, err)
+	}
+
+	caller := a2aTenant(protocol.MessageSendParams{ContextID: contextID, Metadata: params.Metadata})
+	if caller != tenant {
+		log.Printf("Refused push notification configs of task %s to a caller in %s", params.ID, caller)
+		return taskError(params.ID, task.ErrNotFound)
+	}
+	return nil
+}
+
+// pushConfigError converts a push config store error to its A2A error
+func pushConfigError(taskID string, err error) *protocol.Error {
+	if errors.Is(err, store.ErrPushConfigNotFound) {
+		return &protocol.Error{Code: protocol.CodeTaskNotFound, Message: fmt.Sprintf("Push notification config not found for task %s", taskID)}
+	}
+	log.Printf("Error changing push notification configs: %v", err)
+	return protocol.InternalError(err.Error())
+}
+
+// pushConfigResult is a config as A2A's TaskPushNotificationConfig. The
+// credentials are never sent back.
+func pushConfigResult(config store.PushConfig) protocol.TaskPushNotificationConfig {
+	pushConfig := protocol.PushNotificationConfig{ID: config.ID, URL: config.URL, Token: config.Token}
+	if len(config.AuthSchemes) > 0 {
+		pushConfig.Authentication = &protocol.PushAuthentication{Schemes: config.AuthSchemes}
+	}
+	return protocol.TaskPushNotificationConfig{TaskID: config.TaskID, PushNotificationConfig: pushConfig}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	a2alogic "hazel_ai/internal/a2a"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/store"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newPushTestServer is a test server with push notifications turned on
+func newPushTestServer(t *testing.T) (*testServer, *a2alogic.PushSender) {
+	t.Helper()
+	s := newTestServer(t)
+	configs, err := store.NewPushConfigs(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := a2alogic.NewPushSender(1, time.Millisecond)
+	s.handler.SetPushNotifications(configs, sender)
+	return s, sender
+}
+
+func TestPushConfigRefusesPrivateURLs(t *testing.T) {
+	s, sender := newPushTestServer(t)
+	task := s.send(t, "ctx", "list birthdays")
+
+	set := func(url string) *protocol.Error {
+		_, rpcErr := s.call(t, protocol.MethodPushConfigSet, map[string]any{
+			"taskId":                 task.ID,
+			"pushNotificationConfig": map[string]any{"url": url},
+		})
+		return rpcErr
+	}
+
+	for _, url := range []string{"http://localhost:9000/hook", "http://127.0.0.1/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook"} {
+		if rpcErr := set(url); rpcErr == nil || rpcErr.Code != protocol.CodeInvalidParams {
+			t.Errorf("set %s: error = %v, want invalid params", url, rpcErr)
+		}
+	}
+	if rpcErr := set("https://example.com/hook"); rpcErr != nil {
+		t.Errorf("set a public URL: %v", rpcErr)
+	}
+
+	sender.AllowPrivateHosts = true
+	if rpcErr := set("http://127.0.0.1/hook"); rpcErr != nil {
+		t.Errorf("set a private URL while allowed: %v", rpcErr)
+	}
+}
+
+func TestPushConfigTenantCheck(t *testing.T) {
+	s, _ := newPushTestServer(t)
+
+	message := protocol.NewMessage(protocol.RoleUser, "list birthdays")
+	message.ContextID = "ctx"
+	message.Metadata = map[string]any{"telex_channel_id": "c1"}
+	result, rpcErr := s.call(t, protocol.MethodMessageSend, protocol.MessageSendParams{Message: message})
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var task protocol.Task
+	if err := json.Unmarshal(result, &task); err != nil {
+		t.Fatal(err)
+	}
+
+	result, rpcErr = s.call(t, protocol.MethodPushConfigSet, map[string]any{
+		"taskId":                 task.ID,
+		"pushNotificationConfig": map[string]any{"url": "https://example.com/hook"},
+	})
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var saved protocol.TaskPushNotificationConfig
+	if err := json.Unmarshal(result, &saved); err != nil {
+		t.Fatal(err)
+	}
+	configID := saved.PushNotificationConfig.ID
+
+	callers := []struct {
+		name     string
+		metadata map[string]any
+		allowed  bool
+	}{
+		{"no channel", nil, false},
+		{"another channel", map[string]any{"telex_channel_id": "c2"}, false},
+		{"the task's org", map[string]any{"org_id": "c1"}, false},
+		{"the task's channel", map[string]any{"telex_channel_id": "c1"}, true},
+	}
+	for _, caller := range callers {
+		t.Run(caller.name, func(t *testing.T) {
+			params := map[string]any{"id": task.ID, "pushNotificationConfigId": configID, "metadata": caller.metadata}
+			for _, method := range []string{protocol.MethodPushConfigGet, protocol.MethodPushConfigList} {
+				_, rpcErr := s.call(t, method, params)
+				if caller.allowed && rpcErr != nil {
+					t.Errorf("%s: %v", method, rpcErr)
+				}
+				if !caller.allowed && (rpcErr == nil || rpcErr.Code != protocol.CodeTaskNotFound) {
+					t.Errorf("%s: error = %v, want task not found", method, rpcErr)
+				}
+			}
+			if !caller.allowed {
+				if _, rpcErr := s.call(t, protocol.MethodPushConfigDelete, params); rpcErr == nil || rpcErr.Code != protocol.CodeTaskNotFound {
+					t.Errorf("delete: error = %v, want task not found", rpcErr)
+				}
+			}
+		})
+	}
+
+	params := map[string]any{"id": task.ID, "pushNotificationConfigId": configID, "metadata": map[string]any{"telex_channel_id": "c1"}}
+	if _, rpcErr := s.call(t, protocol.MethodPushConfigDelete, params); rpcErr != nil {
+		t.Errorf("delete by the task's channel: %v", rpcErr)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/task"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// handleMessageStream processes message/stream A2A requests. The reply is a
+// stream of Server-Sent Events: the working task, the reply artifact in
+// pieces as Gemini writes it, the whole artifact once it is finished and
+// last a status update marked final. A client that disconnects doesn't stop
+// the task; it can be fetched with tasks/get.
+func (h *Handler) handleMessageStream(c *fiber.Ctx, req protocol.Request) error {
+	m, rpcErr := h.messageParams(c, req)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+	t, ctx, cancel, rpcErr := h.startTask(m)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+	m.Context = ctx
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		events := &eventStream{w: w, id: req.ID, taskID: t.ID}
+
+		if working, err := h.tasks.Get(t.ID, task.AllHistory); err == nil {
+			events.send(working)
+		}
+
+		artifactID := uuid.New().String()
+		chunks := 0
+		m.Partial = func(text string) {
+			piece := protocol.Artifact{ArtifactID: artifactID, Name: "reply", Parts: protocol.TextParts(text)}
+			events.send(protocol.NewArtifactUpdate(t, piece, chunks > 0, false))
+			chunks++
+		}
+
+		done := h.finishTask(t.ID, m, h.reply(m), artifactID)
+		if done.Status.State == protocol.StateCompleted && len(done.Artifacts) > 0 {
+			// The finished reply replaces the pieces, which may have run
+			// over the length limit or come from a provider that failed
+			events.send(protocol.NewArtifactUpdate(done, done.Artifacts[len(done.Artifacts)-1], false, true))
+		}
+		events.send(protocol.NewStatusUpdate(done, true))
+		log.Printf("Streamed task %s in %d pieces", t.ID, chunks)
+	})
+	return nil
+}
+
+// eventStream writes JSON-RPC responses as Server-Sent Events. Once the
+// client has gone, further events are dropped.
+type eventStream struct {
+	w      *bufio.Writer
+	id     json.RawMessage
+	taskID string
+	gone   bool
+}
+
+// send writes one event carrying result and flushes it to the client
+func (s *eventStream) send(result any) {
+	if s.gone {
+		return
+	}
+
+	data, err := json.Marshal(protocol.NewResponse(s.id, result))
+	if err != nil {
+		log.Printf("Failed to encode stream event for task %s: %v", s.taskID, err)
+		return
+	}
+
+	if _, err = s.w.WriteString("data: " + string(data) + "\n\n"); err == nil {
+		err = s.w.Flush()
+	}
+	if err != nil {
+		s.gone = true
+		log.Printf("Client left the stream for task %s, the task carries on: %v", s.taskID, err)
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/intent"
+	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// runTask answers the message as an A2A task. Unless the request's
+// configuration sets "blocking": false the reply waits for the task to
+// finish; otherwise the working task is returned and can be polled with
+// tasks/get.
+func (h *Handler) runTask(c *fiber.Ctx, m intent.Message, req protocol.Request) error {
+	t, ctx, cancel, rpcErr := h.startTask(m)
+	if rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+	m.Context = ctx
+
+	run := func() protocol.Task {
+		defer cancel()
+		return h.finishTask(t.ID, m, h.reply(m), "")
+	}
+
+	configuration := m.Params.Configuration
+	if configuration == nil {
+		configuration = &protocol.MessageSendConfiguration{}
+	}
+	historyLength := historyParam(configuration.HistoryLength)
+	if configuration.Blocking != nil && !*configuration.Blocking {
+		go run()
+		working, err := h.tasks.Get(t.ID, historyLength)
+		if err != nil {
+			return h.rpcError(c, req, taskError(t.ID, err))
+		}
+		return h.sendResult(c, req, working)
+	}
+	return h.sendResult(c, req, limitHistory(run(), historyLength))
+}
+
+// startTask finds the task the message belongs to and marks it as working.
+// The message joins the task named by its taskId, or the task in its context
+// that is waiting for an answer, or else starts a new one. A push
+// notification config in the request's configuration is registered for the
+// task when it can be used. The returned context is canceled by
+// tasks/cancel; the caller calls cancel once the task is done. On error the
+// task holds only the ID that failed.
+func (h *Handler) startTask(m intent.Message) (protocol.Task, context.Context, context.CancelFunc, *protocol.Error) {
+	taskID, contextID := m.Params.EffectiveTaskID(), m.Params.EffectiveContextID()
+
+	// Telex sends its webhook with every message, so one Hazel can't use,
+	// or any at all while push notifications are off, is skipped rather
+	// than failing the message
+	var push *store.PushConfig
+	if configuration := m.Params.Configuration; configuration != nil && configuration.PushNotificationConfig != nil {
+		config, rpcErr := h.newPushConfig(*configuration.PushNotificationConfig)
+		if rpcErr != nil {
+			log.Printf("Ignoring the push notification config sent with message %s: %s", m.Params.Message.MessageID, rpcErr.Message)
+		} else {
+			push = &config
+		}
+	}
+
+	// The history keeps every part the user sent, data and files included
+	userMessage := protocol.NewMessage(protocol.RoleUser, m.Text)
+	if len(m.Params.Message.Parts) > 0 {
+		userMessage.Parts = m.Params.Message.Parts
+	}
+	if m.Params.Message.MessageID != "" {
+		userMessage.MessageID = m.Params.Message.MessageID
+	}
+
+	if taskID == "" && contextID != "" {
+		if pending, ok := h.tasks.Pending(m.Tenant, contextID); ok {
+			taskID = pending.ID
+		}
+	}
+
+	var t protocol.Task
+	if taskID == "" {
+		t = h.tasks.Create(m.Tenant, contextID, m.ConversationKey, userMessage)
+	} else {
+		var err error
+		if t, err = h.tasks.Continue(m.Tenant, taskID, userMessage); err != nil {
+			return protocol.Task{ID: taskID}, nil, nil, taskError(taskID, err)
+		}
+	}
+
+	if push != nil {
+		push.TaskID, push.ContextID, push.Tenant = t.ID, t.ContextID, m.Tenant
+		if _, err := h.pushConfigs.Set(*push); err != nil {
+			log.Printf("Error saving push notification config for task %s: %v", t.ID, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := h.tasks.Start(t.ID, cancel); err != nil {
+		cancel()
+		return protocol.Task{ID: t.ID}, nil, nil, taskError(t.ID, err)
+	}
+	return t, ctx, cancel, nil
+}
+
+// finishTask records the reply and moves the task to the state it leaves
+// the conversation in. A completed task gets the reply as an artifact with
+// artifactID, or a new ID when it is empty.
+func (h *Handler) finishTask(id string, m intent.Message, reply intent.Reply, artifactID string) protocol.Task {
+	state := protocol.StateCompleted
+	var artifacts []protocol.Artifact
+	switch {
+	case reply.Failed:
+		state = protocol.StateFailed
+	case h.awaitingInput(m.ConversationKey):
+		state = protocol.StateInputRequired
+	default:
+		artifact := protocol.NewArtifact("reply", reply.Text)
+		artifact.Parts = replyParts(reply)
+		if artifactID != "" {
+			artifact.ArtifactID = artifactID
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	agentMessage := protocol.NewMessage(protocol.RoleAgent, reply.Text)
+	agentMessage.Parts = replyParts(reply)
+	t, err := h.tasks.Finish(id, state, agentMessage, artifacts...)
+	if err != nil {
+		// Only happens if the task expired while it was running
+		log.Printf("Failed to finish task %s: %v", id, err)
+		return protocol.Task{ID: id, Kind: "task", Status: protocol.Status{State: state, Message: &agentMessage}}
+	}
+	log.Printf("Task %s is %s", id, t.Status.State)
+	h.pushTask(t)
+	return t
+}
+
+// replyParts is the reply as message parts: the text, then the
+// machine-readable result when there is one
+func replyParts(reply intent.Reply) []protocol.Part {
+	parts := protocol.TextParts(reply.Text)
+	if reply.Data != nil {
+		parts = append(parts, protocol.DataPart(reply.Data))
+	}
+	return parts
+}
+
+// awaitingInput reports whether Hazel asked a question in the conversation
+// and is waiting for the answer. An offer to undo isn't a question.
+func (h *Handler) awaitingInput(conversationKey string) bool {
+	state, ok := h.conversations.Get(conversationKey)
+	return ok && state.Awaiting != conversation.SlotUndo
+}
+
+// handleTasksGet processes tasks/get A2A requests
+func (h *Handler) handleTasksGet(c *fiber.Ctx, req protocol.Request) error {
+	var params protocol.TaskQueryParams
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	t, err := h.tasks.Get(params.ID, historyParam(params.HistoryLength))
+	if err != nil {
+		return h.rpcError(c, req, taskError(params.ID, err))
+	}
+	return h.sendResult(c, req, t)
+}
+
+// handleTasksCancel processes tasks/cancel A2A requests
+func (h *Handler) handleTasksCancel(c *fiber.Ctx, req protocol.Request) error {
+	var params protocol.TaskIDParams
+	if rpcErr := req.DecodeParams(&params); rpcErr != nil {
+		return h.rpcError(c, req, rpcErr)
+	}
+
+	key, _ := h.tasks.ConversationKey(params.ID)
+	t, err := h.tasks.Cancel(params.ID)
+	if err != nil {
+		return h.rpcError(c, req, taskError(params.ID, err))
+	}
+	// Drop any question the task asked so the next message starts afresh
+	if key != "" {
+		h.conversations.Clear(key)
+	}
+	log.Printf("Task %s canceled", params.ID)
+	h.pushTask(t)
+	return h.sendResult(c, req, t)
+}
+
+// historyParam reads an optional historyLength param. Leaving it out asks
+// for the whole history; 0 asks for none.
+func historyParam(n *int) int {
+	if n == nil {
+		return task.AllHistory
+	}
+	return *n
+}
+
+// limitHistory keeps the last historyLength messages of the task's history,
+// or all of them when historyLength is task.AllHistory
+func limitHistory(t protocol.Task, historyLength int) protocol.Task {
+	if historyLength >= 0 && len(t.History) > historyLength {
+		t.History = t.History[len(t.History)-historyLength:]
+	}
+	return t
+}
+
+// sendResult sends a successful JSON-RPC response
+func (h *Handler) sendResult(c *fiber.Ctx, req protocol.Request, result any) error {
+	return c.Status(200).JSON(protocol.NewResponse(req.ID, result))
+}
+
+// taskError converts a task store error to its A2A error
+func taskError(id string, err error) *protocol.Error {
+	switch {
+	case errors.Is(err, task.ErrNotFound):
+		return protocol.TaskNotFound(id)
+	case errors.Is(err, task.ErrNotCancelable):
+		return protocol.TaskNotCancelable(id)
+	case errors.Is(err, task.ErrFinished):
+		return protocol.InvalidParams(fmt.Sprintf("task %s has already finished, start a new one", id))
+	default:
+		return protocol.InternalError(err.Error())
+	}
+}
+
+// rpcError sends a JSON-RPC error response, with an HTTP status matching the
+// error
+func (h *Handler) rpcError(c *fiber.Ctx, req protocol.Request, rpcErr *protocol.Error) error {
+	status := fiber.StatusBadRequest
+	switch rpcErr.Code {
+	case protocol.CodeTaskNotFound:
+		status = fiber.StatusNotFound
+	case protocol.CodeTaskNotCancelable:
+		status = fiber.StatusConflict
+	case protocol.CodeInternalError:
+		status = fiber.StatusInternalServerError
+	}
+	return c.Status(status).JSON(protocol.NewErrorResponse(req.ID, rpcErr))
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hazel_ai/internal/a2a/protocol"
+	"testing"
+)
+
+func TestTasksGetHistoryLength(t *testing.T) {
+	s := newTestServer(t)
+	sent := s.send(t, "ctx", "help")
+	if len(sent.History) != 2 {
+		t.Fatalf("message/send returned %d messages of history, want 2", len(sent.History))
+	}
+
+	zero, one := 0, 1
+	tests := []struct {
+		name          string
+		historyLength *int
+		want          int
+	}{
+		{"absent returns all", nil, 2},
+		{"zero returns none", &zero, 0},
+		{"one returns the reply", &one, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, rpcErr := s.call(t, protocol.MethodTasksGet, protocol.TaskQueryParams{ID: sent.ID, HistoryLength: tt.historyLength})
+			if rpcErr != nil {
+				t.Fatal(rpcErr)
+			}
+			var got protocol.Task
+			if err := json.Unmarshal(result, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.History) != tt.want {
+				t.Errorf("got %d messages of history, want %d", len(got.History), tt.want)
+			}
+		})
+	}
+}
+
+func TestTasksGetUnknownTask(t *testing.T) {
+	s := newTestServer(t)
+	_, rpcErr := s.call(t, protocol.MethodTasksGet, protocol.TaskQueryParams{ID: "missing"})
+	if rpcErr == nil || rpcErr.Code != protocol.CodeTaskNotFound {
+		t.Errorf("error = %v, want code %d", rpcErr, protocol.CodeTaskNotFound)
+	}
+}
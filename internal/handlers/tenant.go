@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/store"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// tenantLocalsKey is where APIKeyAuth stores the REST caller's tenant
+const tenantLocalsKey = "tenant"
+
+// Metadata keys that identify the Telex channel or organization a message
+// came from, in order of preference
+var (
+	channelMetadataKeys = []string{"telex_channel_id", "channel_id", "channelId"}
+	orgMetadataKeys     = []string{"telex_org_id", "org_id", "orgId", "organization_id"}
+)
+
+// APIKeyAuth resolves the REST caller's tenant from the X-API-Key header.
+// keys maps each API key to the tenant it may access. With no keys
+// configured every caller shares the default tenant.
+func APIKeyAuth(keys map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(keys) == 0 {
+			c.Locals(tenantLocalsKey, store.DefaultTenant)
+			return c.Next()
+		}
+
+		tenant, ok := keys[c.Get("X-API-Key")]
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing or invalid API key"})
+		}
+
+		c.Locals(tenantLocalsKey, tenant)
+		return c.Next()
+	}
+}
+
+// restTenant returns the tenant resolved by APIKeyAuth
+func restTenant(c *fiber.Ctx) string {
+	if tenant, ok := c.Locals(tenantLocalsKey).(string); ok {
+		return tenant
+	}
+	return store.DefaultTenant
+}
+
+// a2aTenant derives the birthday book for an A2A request: the Telex channel
+// if the message metadata names one, then the organization, then the A2A
+// context ID, which scopeParams gives every request without the others
+func a2aTenant(params protocol.MessageSendParams) string {
+	metadata := requestMetadata(params)
+
+	if id := lookupString(metadata, channelMetadataKeys); id != "" {
+		return "channel:" + id
+	}
+	if id := lookupString(metadata, orgMetadataKeys); id != "" {
+		return "org:" + id
+	}
+	return "context:" + params.EffectiveContextID()
+}
+
+// scopeParams gives an A2A request naming no channel, org or context the
+// context of the task it continues, or else a new context of its own. An
+// unscoped request gets an empty birthday book rather than the default one
+// shared with every other unscoped caller.
+func (h *Handler) scopeParams(params protocol.MessageSendParams) protocol.MessageSendParams {
+	metadata := requestMetadata(params)
+	if params.EffectiveContextID() != "" || lookupString(metadata, channelMetadataKeys) != "" || lookupString(metadata, orgMetadataKeys) != "" {
+		return params
+	}
+	if taskID := params.EffectiveTaskID(); taskID != "" {
+		if t, err := h.tasks.Get(taskID, 0); err == nil {
+			params.Message.ContextID = t.ContextID
+			return params
+		}
+	}
+	params.Message.ContextID = uuid.New().String()
+	log.Printf("No context in A2A request, starting context %s", params.Message.ContextID)
+	return params
+}
+
+// requestMetadata returns the message metadata and the params metadata of an
+// A2A request, message first since it is the more specific of the two
+func requestMetadata(params protocol.MessageSendParams) []map[string]any {
+	metadata := []map[string]any{}
+	if params.Message.Metadata != nil {
+		metadata = append(metadata, params.Message.Metadata)
+	}
+	if params.Metadata != nil {
+		metadata = append(metadata, params.Metadata)
+	}
+	return metadata
+}
+
+// lookupString returns the first non-empty string found under any of keys
+func lookupString(maps []map[string]any, keys []string) string {
+	for _, key := range keys {
+		for _, m := range maps {
+			if v, ok := m[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"hazel_ai/internal/store"
+	"strings"
+	"testing"
+)
+
+func TestUnscopedRequestsGetTheirOwnBook(t *testing.T) {
+	s := newTestServer(t)
+	if _, err := s.store.AddBirthday(store.NewBirthday{Name: "Zed", Date: "05-05", Tenant: store.DefaultTenant}); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply := s.chat(t, "", "list birthdays"); strings.Contains(reply, "Zed") {
+		t.Errorf("unscoped list = %q, want the default book's birthdays left out", reply)
+	}
+
+	// The new context is the request's book, so following it up works
+	saved := s.send(t, "", "Remember Bob's birthday is March 3rd")
+	if saved.ContextID == "" {
+		t.Fatal("unscoped task has no context ID")
+	}
+	if reply := s.chat(t, saved.ContextID, "list birthdays"); !strings.Contains(reply, "Bob") || strings.Contains(reply, "Zed") {
+		t.Errorf("list in the task's context = %q, want Bob and not Zed", reply)
+	}
+	if reply := s.chat(t, "", "list birthdays"); strings.Contains(reply, "Bob") {
+		t.Errorf("second unscoped list = %q, want a book of its own", reply)
+	}
+
+	defaults, err := s.store.List(store.DefaultTenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defaults) != 1 {
+		t.Errorf("default book has %d birthdays, want only Zed", len(defaults))
+	}
+}
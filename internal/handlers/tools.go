@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
+	"hazel_ai/internal/intent"
+	"strings"
+	"time"
+)
+
+// maxUpcomingDays caps how far ahead the upcoming_birthdays tool looks
+const maxUpcomingDays = 366
+
+// birthdayTools are the functions Gemini may call in tool-calling mode
+var birthdayTools = []clients.Tool{
+	{
+		Name:        "add_birthday",
+		Description: "Save someone's birthday.",
+		Params: []clients.ToolParam{
+			{Name: "name", Type: "string", Description: "Whose birthday it is, or \"me\" for the sender's own", Required: true},
+			{Name: "date", Type: "string", Description: "The birthday as YYYY-MM-DD, or MM-DD when the year isn't given"},
+		},
+	},
+	{
+		Name:        "list_birthdays",
+		Description: "List every saved birthday.",
+	},
+	{
+		Name:        "upcoming_birthdays",
+		Description: "List the birthdays coming up soon.",
+		Params: []clients.ToolParam{
+			{Name: "days", Type: "integer", Description: "How many days ahead to look (default 30)"},
+		},
+	},
+	{
+		Name:        "generate_wish",
+		Description: "Write a birthday wish.",
+		Params: []clients.ToolParam{
+			{Name: "name", Type: "string", Description: "Who the wish is for; leave out for a generic wish"},
+			{Name: "tone", Type: "string", Description: "How the wish should sound: " + strings.Join(clients.Tones, ", ") + " (default warm)"},
+			{Name: "language", Type: "string", Description: "The language to write in, as a code such as \"es\" or a name such as \"Spanish\" (default English)"},
+			{Name: "relationship", Type: "string", Description: "Who the person is to the sender, e.g. \"manager\" or \"sister\""},
+			{Name: "max_words", Type: "integer", Description: fmt.Sprintf("The longest the wish may be, in words (%d to %d, default %d)", clients.MinWishWords, clients.MaxWishWords, clients.DefaultMaxWords)},
+		},
+	},
+	{
+		Name:        "delete_birthday",
+		Description: "Forget a saved birthday. The user is asked to confirm first.",
+		Params: []clients.ToolParam{
+			{Name: "name", Type: "string", Description: "Whose birthday to forget, or \"me\" for the sender's own", Required: true},
+		},
+	},
+}
+
+// SetToolCalling routes chat messages through Gemini function calling with
+// the given client, or turns it off when client is nil. The intent router is
+// still used when Gemini fails or doesn't pick a tool.
+func (h *Handler) SetToolCalling(client *clients.GeminiClient) {
+	h.toolCaller = client
+}
+
+// callTools asks Gemini which tool the message calls for and runs it
+func (h *Handler) callTools(m intent.Message) (intent.Reply, error) {
+	instructions := fmt.Sprintf("You are Hazel, a birthday bot in a team chat. Call the tool that does what the user asks. Today is %s.",
+		time.Now().Format("Monday, 2006-01-02"))
+	call, err := h.toolCaller.ChooseTool(instructions, m.Text, birthdayTools)
+	if err != nil {
+		return intent.Reply{}, err
+	}
+
+	switch call.Name {
+	case "add_birthday":
+		state := conversation.State{Intent: conversation.IntentRemember, Slots: map[string]string{}}
+		h.fillToolName(&state, m, call.String("name"))
+		if text := call.String("date"); text != "" {
+			date, err := h.dates.Parse(text)
+			var invalid *dateparse.InvalidDateError
+			if errors.As(err, &invalid) {
+				return intent.Reply{Text: fmt.Sprintf("❌ %s isn't a real date: %s. Could you check it and try again?", invalid.Text, invalid.Reason)}, nil
+			}
+			if err != nil {
+				return intent.Reply{}, fmt.Errorf("gemini gave an unreadable date %q: %w", text, err)
+			}
+			fillDate(&state, date)
+		}
+		return h.advanceRemember(m, state), nil
+
+	case "list_birthdays":
+		return h.handleList(m), nil
+
+	case "upcoming_birthdays":
+		days := call.Int("days")
+		if days <= 0 {
+			days = 30
+		}
+		return h.upcomingReply(m.Tenant, min(days, maxUpcomingDays)), nil
+
+	case "generate_wish":
+		name := call.String("name")
+		if name == "" || selfWords[strings.ToLower(name)] {
+			name = "you"
+		}
+		return intent.Reply{Text: h.wishFor(m, clients.WishRequest{
+			Name:         name,
+			Tone:         call.String("tone"),
+			Language:     call.String("language"),
+			Relationship: call.String("relationship"),
+			MaxWords:     call.Int("max_words"),
+		})}, nil
+
+	case "delete_birthday":
+		state := conversation.State{Intent: conversation.IntentForget, Slots: map[string]string{}}
+		h.fillToolName(&state, m, call.String("name"))
+		return h.advanceManage(m, state), nil
+	}
+	return intent.Reply{}, fmt.Errorf("gemini called unknown tool %q", call.Name)
+}
+
+// fillToolName stores a name Gemini passed to a tool, reading "me" as the sender
+func (h *Handler) fillToolName(state *conversation.State, m intent.Message, name string) {
+	name = strings.TrimSpace(name)
+	if selfWords[strings.ToLower(name)] {
+		state.Slots[conversation.SlotSelf] = "true"
+		name = senderDisplayName(m.Params)
+	}
+	state.Slots[conversation.SlotName] = name
+}
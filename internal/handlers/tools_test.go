@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/store"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// fakeGemini is a genai transport that answers every request with a canned
+// function call and keeps the requests it was sent
+type fakeGemini struct {
+	mu       sync.Mutex
+	call     map[string]any
+	requests []map[string]any
+}
+
+func (f *fakeGemini) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, decoded)
+	parts := []map[string]any{{"text": "I don't know which tool to use."}}
+	if f.call != nil {
+		parts = []map[string]any{{"functionCall": f.call}}
+	}
+	f.mu.Unlock()
+
+	response, err := json.Marshal(map[string]any{
+		"candidates": []map[string]any{{"content": map[string]any{"role": "model", "parts": parts}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+// respond makes every later request choose the named tool
+func (f *fakeGemini) respond(name string, args map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.call = map[string]any{"name": name, "args": args}
+}
+
+// declaredParams returns the parameters the last request declared for a tool
+func (f *fakeGemini) declaredParams(t *testing.T, tool string) map[string]any {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		t.Fatal("gemini was never called")
+	}
+
+	tools, _ := f.requests[len(f.requests)-1]["tools"].([]any)
+	for _, entry := range tools {
+		declarations, _ := entry.(map[string]any)["functionDeclarations"].([]any)
+		for _, d := range declarations {
+			declaration := d.(map[string]any)
+			if declaration["name"] != tool {
+				continue
+			}
+			parameters, _ := declaration["parameters"].(map[string]any)
+			properties, _ := parameters["properties"].(map[string]any)
+			return properties
+		}
+	}
+	t.Fatalf("tool %s was not declared", tool)
+	return nil
+}
+
+func newToolServer(t *testing.T) (*testServer, *fakeGemini) {
+	t.Helper()
+	fake := &fakeGemini{}
+	client, err := clients.NewGeminiClientWithConfig(&genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  &http.Client{Transport: fake},
+		HTTPOptions: genai.HTTPOptions{BaseURL: "http://gemini.test/"},
+	}, clients.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t)
+	s.handler.SetToolCalling(client)
+	return s, fake
+}
+
+func TestToolCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup []store.NewBirthday
+		tool  string
+		args  map[string]any
+		check func(t *testing.T, s *testServer, reply string)
+	}{
+		{
+			name: "add_birthday saves the birthday",
+			tool: "add_birthday",
+			args: map[string]any{"name": "Alice", "date": "1990-03-03"},
+			check: func(t *testing.T, s *testServer, reply string) {
+				matches, err := store.FindByName(s.store, testTenant, "Alice")
+				if err != nil || len(matches) != 1 || matches[0].Month != 3 || matches[0].Day != 3 || matches[0].Year != 1990 {
+					t.Errorf("saved %+v, %v; want Alice on 1990-03-03", matches, err)
+				}
+			},
+		},
+		{
+			name: "add_birthday rejects an impossible date",
+			tool: "add_birthday",
+			args: map[string]any{"name": "Alice", "date": "02-30"},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if !strings.Contains(reply, "isn't a real date") {
+					t.Errorf("reply = %q, want the date rejected", reply)
+				}
+			},
+		},
+		{
+			name: "add_birthday without a date asks for it",
+			tool: "add_birthday",
+			args: map[string]any{"name": "Alice"},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if !strings.Contains(reply, "When is Alice's birthday") {
+					t.Errorf("reply = %q, want a question about the date", reply)
+				}
+			},
+		},
+		{
+			name:  "list_birthdays lists the book",
+			setup: []store.NewBirthday{{Name: "Bob", Date: "07-04"}},
+			tool:  "list_birthdays",
+			check: func(t *testing.T, s *testServer, reply string) {
+				if !strings.Contains(reply, "Bob") {
+					t.Errorf("reply = %q, want Bob listed", reply)
+				}
+			},
+		},
+		{
+			name:  "upcoming_birthdays shows the next birthdays",
+			setup: []store.NewBirthday{{Name: "Eve", Date: time.Now().AddDate(0, 0, 3).Format("01-02")}},
+			tool:  "upcoming_birthdays",
+			args:  map[string]any{"days": 7},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if !strings.Contains(reply, "Eve") {
+					t.Errorf("reply = %q, want Eve listed", reply)
+				}
+			},
+		},
+		{
+			name: "generate_wish writes a wish",
+			tool: "generate_wish",
+			args: map[string]any{"name": "Carol", "tone": "formal", "language": "en", "relationship": "manager", "max_words": 40},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if reply == "" || strings.Contains(reply, "❌") {
+					t.Errorf("reply = %q, want a wish", reply)
+				}
+			},
+		},
+		{
+			name: "generate_wish reports bad options",
+			tool: "generate_wish",
+			args: map[string]any{"name": "Carol", "tone": "sarcastic"},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if !strings.Contains(reply, "unknown tone") {
+					t.Errorf("reply = %q, want the tone rejected", reply)
+				}
+			},
+		},
+		{
+			name:  "delete_birthday asks to confirm",
+			setup: []store.NewBirthday{{Name: "Dan", Date: "01-02"}},
+			tool:  "delete_birthday",
+			args:  map[string]any{"name": "Dan"},
+			check: func(t *testing.T, s *testServer, reply string) {
+				if matches, _ := store.FindByName(s.store, testTenant, "Dan"); len(matches) != 1 {
+					t.Error("Dan was deleted without confirmation")
+				}
+				if !strings.Contains(reply, "Dan") {
+					t.Errorf("reply = %q, want a question about Dan", reply)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newToolServer(t)
+			for _, b := range tt.setup {
+				b.Tenant = testTenant
+				if _, err := s.store.AddBirthday(b); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fake.respond(tt.tool, tt.args)
+			reply := s.chat(t, "ctx", "do the thing")
+			tt.check(t, s, reply)
+		})
+	}
+}
+
+func TestToolDeclarations(t *testing.T) {
+	s, fake := newToolServer(t)
+	fake.respond("list_birthdays", nil)
+	s.chat(t, "ctx", "show me everything")
+
+	params := fake.declaredParams(t, "generate_wish")
+	for _, name := range []string{"name", "tone", "language", "relationship", "max_words"} {
+		if _, ok := params[name]; !ok {
+			t.Errorf("generate_wish doesn't declare %s", name)
+		}
+	}
+	if maxWords, _ := params["max_words"].(map[string]any); maxWords["type"] != "INTEGER" {
+		t.Errorf("max_words declared as %v, want INTEGER", maxWords["type"])
+	}
+}
+
+func TestToolCallingFallsBackToRouter(t *testing.T) {
+	s, fake := newToolServer(t)
+
+	// No function call
+	if reply := s.chat(t, "ctx", "help"); !strings.Contains(reply, "I can help you with") {
+		t.Errorf("reply without a tool call = %q, want the router's help text", reply)
+	}
+
+	// A tool that doesn't exist
+	fake.respond("launch_rockets", nil)
+	if reply := s.chat(t, "ctx", "help"); !strings.Contains(reply, "I can help you with") {
+		t.Errorf("reply for an unknown tool = %q, want the router's help text", reply)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.requests) != 2 {
+		t.Errorf("gemini was asked %d times, want 2", len(fake.requests))
+	}
+}
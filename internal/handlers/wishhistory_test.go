@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/store"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newHistoryTestServer is a test server that records wishes, with Alice's
+// birthday saved in the default book
+func newHistoryTestServer(t *testing.T) (*testServer, *store.WishHistory, string) {
+	t.Helper()
+	s := newTestServer(t)
+	history, err := store.NewWishHistory(filepath.Join(t.TempDir(), "wishes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.handler.wishHistory = history
+
+	id, err := s.store.AddBirthday(store.NewBirthday{Name: "Alice", Date: "03-03", Tenant: store.DefaultTenant})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, history, id
+}
+
+func TestGenerateWishAvoidsRecentWishes(t *testing.T) {
+	s, history, id := newHistoryTestServer(t)
+	earlier := store.WishRecord{BirthdayID: id, Text: "An earlier wish", Source: clients.ProviderTemplate, Template: "wish-warm.tmpl", CreatedAt: time.Now().Add(-time.Hour)}
+	if err := history.Add(earlier); err != nil {
+		t.Fatal(err)
+	}
+	writer := &draftWriter{}
+	s.handler.wishes = writer
+
+	var resp wishResponse
+	if status := s.rest(t, "POST", "/api/wishes/generate", `{"name": "Alice"}`, &resp); status != 200 {
+		t.Fatalf("status = %d (%s), want 200", status, resp.Error)
+	}
+	req := writer.lastRequest()
+	if !slices.Contains(req.Avoid, earlier.Text) || !slices.Contains(req.AvoidTemplates, earlier.Template) {
+		t.Errorf("provider asked to avoid %v and templates %v, want the earlier wish and its template", req.Avoid, req.AvoidTemplates)
+	}
+
+	list := history.List(id)
+	if len(list) != 2 || list[0].Text != resp.Wish {
+		t.Errorf("history = %+v, want the new wish recorded first", list)
+	}
+
+	// Someone without a saved birthday has no history to record to
+	if status := s.rest(t, "POST", "/api/wishes/generate", `{"name": "Bob"}`, nil); status != 200 {
+		t.Fatalf("status for Bob = %d, want 200", status)
+	}
+	if n := len(history.List(id)); n != 2 {
+		t.Errorf("Alice has %d wishes after Bob's, want 2", n)
+	}
+}
+
+func TestTemplateWishesDontRepeat(t *testing.T) {
+	s, history, id := newHistoryTestServer(t)
+
+	// There are two warm English templates, so two wishes in a row differ
+	for range 2 {
+		if status := s.rest(t, "POST", "/api/wishes/generate", `{"name": "Alice"}`, nil); status != 200 {
+			t.Fatalf("status = %d, want 200", status)
+		}
+	}
+	list := history.List(id)
+	if len(list) != 2 {
+		t.Fatalf("history has %d wishes, want 2", len(list))
+	}
+	if list[0].Template == list[1].Template || list[0].Text == list[1].Text {
+		t.Errorf("both wishes came from %s: %q", list[0].Template, list[0].Text)
+	}
+}
+
+func TestListBirthdayWishes(t *testing.T) {
+	s, history, id := newHistoryTestServer(t)
+	start := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	for i, text := range []string{"first", "second", "third"} {
+		if err := history.Add(store.WishRecord{BirthdayID: id, Text: text, Source: "gemini", CreatedAt: start.AddDate(i, 0, 0)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var resp struct {
+		ID     string             `json:"id"`
+		Name   string             `json:"name"`
+		Wishes []store.WishRecord `json:"wishes"`
+		Total  int                `json:"total"`
+	}
+	if status := s.rest(t, "GET", "/api/birthdays/"+id+"/wishes", "", &resp); status != 200 {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if resp.ID != id || resp.Name != "Alice" || resp.Total != 3 || len(resp.Wishes) != 3 {
+		t.Fatalf("response = %+v, want Alice's 3 wishes", resp)
+	}
+	if resp.Wishes[0].Text != "third" || resp.Wishes[2].Text != "first" {
+		t.Errorf("wishes = %+v, want newest first", resp.Wishes)
+	}
+
+	if status := s.rest(t, "GET", "/api/birthdays/missing/wishes", "", nil); status != 404 {
+		t.Errorf("status for an unknown birthday = %d, want 404", status)
+	}
+
+	// Another book's birthday isn't found
+	other, err := s.store.AddBirthday(store.NewBirthday{Name: "Grace", Date: "12-09", Tenant: "team-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := s.rest(t, "GET", "/api/birthdays/"+other+"/wishes", "", nil); status != 404 {
+		t.Errorf("status for another book's birthday = %d, want 404", status)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"hazel_ai/internal/clients"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Lengths for "a short wish" and "a long wish"
+const (
+	shortWishWords = 40
+	longWishWords  = 200
+)
+
+var (
+	// "in 50 words", "under 30 words", "a 20-word wish"
+	wishWordsRe  = regexp.MustCompile(`(?i)\b(?:(?:in|under|within|max|at most)\s+)?(\d+)[\s-]+words?\b`)
+	wishLengthRe = regexp.MustCompile(`(?i)\b(short|brief|long)\b`)
+	// "in Spanish"
+	wishLanguageRe = regexp.MustCompile(`(?i)\bin\s+([a-z]{3,})\b`)
+	wishToneRe     = regexp.MustCompile(`(?i)\b(warm|heartfelt|funny|humorous|formal|professional|poetic|poem)\b`)
+	// "my manager", "our grandma"
+	wishRelationshipRe = regexp.MustCompile(`(?i)\b(?:my|our)\s+(` + strings.Join(relationshipWords, "|") + `)\b`)
+)
+
+// relationshipWords are the relationships recognised after "my" in chat
+var relationshipWords = []string{
+	"manager", "boss", "coworker", "co-worker", "colleague", "teammate", "team lead", "mentor",
+	"mom", "mum", "mother", "dad", "father", "sister", "brother", "wife", "husband", "partner",
+	"girlfriend", "boyfriend", "son", "daughter", "grandma", "grandmother", "grandpa", "grandfather",
+	"aunt", "uncle", "cousin", "friend", "best friend", "neighbour", "neighbor",
+}
+
+// toneWords maps the words people use for a tone onto clients.Tones
+var toneWords = map[string]string{
+	"warm": clients.ToneWarm, "heartfelt": clients.ToneWarm,
+	"funny": clients.ToneFunny, "humorous": clients.ToneFunny,
+	"formal": clients.ToneFormal, "professional": clients.ToneFormal,
+	"poetic": clients.TonePoetic, "poem": clients.TonePoetic,
+}
+
+// parseWishOptions reads the tone, length, language and relationship from a
+// chat request such as "a short funny wish for my manager Alice in Spanish".
+// It returns the options and the text with them cut out, so the name can be
+// found in what is left.
+func parseWishOptions(text string) (clients.WishRequest, string) {
+	var req clients.WishRequest
+
+	if m := wishWordsRe.FindStringSubmatch(text); m != nil {
+		req.MaxWords, _ = strconv.Atoi(m[1])
+		text = wishWordsRe.ReplaceAllString(text, " ")
+	}
+	if m := wishLengthRe.FindStringSubmatch(text); m != nil {
+		if req.MaxWords == 0 {
+			req.MaxWords = shortWishWords
+			if strings.EqualFold(m[1], "long") {
+				req.MaxWords = longWishWords
+			}
+		}
+		text = wishLengthRe.ReplaceAllString(text, " ")
+	}
+
+	for _, m := range wishLanguageRe.FindAllStringSubmatchIndex(text, -1) {
+		if code, ok := clients.LanguageCode(text[m[2]:m[3]]); ok {
+			req.Language = code
+			text = text[:m[0]] + " " + text[m[1]:]
+			break
+		}
+	}
+
+	if m := wishToneRe.FindStringSubmatch(text); m != nil {
+		req.Tone = toneWords[strings.ToLower(m[1])]
+		text = wishToneRe.ReplaceAllString(text, " ")
+	}
+
+	if m := wishRelationshipRe.FindStringSubmatch(text); m != nil {
+		req.Relationship = strings.ToLower(m[1])
+		text = wishRelationshipRe.ReplaceAllString(text, " ")
+	}
+
+	return req, strings.Join(strings.Fields(text), " ")
+}
+
+// wishBody is the JSON body of POST /api/wishes/generate and
+// POST /api/wishes/sessions
+type wishBody struct {
+	Name         string   `json:"name"`
+	Age          int      `json:"age,omitempty"`
+	Tone         string   `json:"tone,omitempty"`
+	MaxWords     int      `json:"max_words,omitempty"`
+	Language     string   `json:"language,omitempty"`
+	Relationship string   `json:"relationship,omitempty"`
+	Interests    []string `json:"interests,omitempty"`
+	Notes        string   `json:"notes,omitempty"`
+}
+
+func (b wishBody) request() clients.WishRequest {
+	return clients.WishRequest{
+		Name:         b.Name,
+		Age:          b.Age,
+		Tone:         b.Tone,
+		Language:     b.Language,
+		Relationship: b.Relationship,
+		MaxWords:     b.MaxWords,
+		Interests:    b.Interests,
+		Notes:        b.Notes,
+	}
+}
+
+// wishOptionsFromQuery reads tone, max_words, language and relationship from
+// the query string of the GET wish endpoints
+func wishOptionsFromQuery(c *fiber.Ctx) (clients.WishRequest, error) {
+	req := clients.WishRequest{
+		Tone:         c.Query("tone"),
+		Language:     c.Query("language"),
+		Relationship: c.Query("relationship"),
+	}
+	if maxWords := c.Query("max_words"); maxWords != "" {
+		n, err := strconv.Atoi(maxWords)
+		if err != nil {
+			return req, fmt.Errorf("max_words must be a number")
+		}
+		req.MaxWords = n
+	}
+	return req, nil
+}
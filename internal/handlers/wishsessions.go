@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/wishsession"
+	"log"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits on how many candidates one request may ask for
+const (
+	defaultWishCandidates = 3
+	maxWishCandidates     = 5
+)
+
+// CreateWishSession generates several candidate wishes and keeps them so
+// they can be regenerated with feedback
+func (h *Handler) CreateWishSession(c *fiber.Ctx) error {
+	type CreateWishSessionRequest struct {
+		wishBody
+		Count int `json:"count,omitempty"`
+	}
+
+	var req CreateWishSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	}
+	count, ok := candidateCount(req.Count)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "count must be between 1 and 5"})
+	}
+
+	wishReq := req.request()
+	if err := wishReq.Validate(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	// Candidates are drafts, so they are kept out of the wish history
+	h.personalizeByName(restTenant(c), &wishReq)
+
+	candidates := clients.CandidatesOrFallback(c.Context(), h.wishes, wishReq, count)
+	session := h.wishSessions.Create(restTenant(c), wishReq, candidates)
+	log.Printf("Started wish session %s for %s with %d candidates", session.ID, wishReq.Name, len(candidates))
+
+	return c.Status(201).JSON(wishSessionResponse(session))
+}
+
+// GetWishSession returns a wish session and every round of candidates
+func (h *Handler) GetWishSession(c *fiber.Ctx) error {
+	session, ok := h.wishSessions.Get(restTenant(c), c.Params("id"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Wish session not found or expired"})
+	}
+	return c.Status(200).JSON(wishSessionResponse(session))
+}
+
+// RegenerateWishSession writes new candidates for a session, taking the
+// caller's feedback into account and avoiding every earlier candidate
+func (h *Handler) RegenerateWishSession(c *fiber.Ctx) error {
+	type RegenerateRequest struct {
+		Feedback string `json:"feedback,omitempty"`
+		Count    int    `json:"count,omitempty"`
+	}
+
+	var req RegenerateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	count, ok := candidateCount(req.Count)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "count must be between 1 and 5"})
+	}
+
+	tenant := restTenant(c)
+	session, ok := h.wishSessions.Get(tenant, c.Params("id"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Wish session not found or expired"})
+	}
+
+	wishReq := session.Request
+	wishReq.Feedback = req.Feedback
+	wishReq.Avoid = slices.Concat(session.Request.Avoid, session.Previous())
+	if err := wishReq.Validate(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	candidates := clients.CandidatesOrFallback(c.Context(), h.wishes, wishReq, count)
+	session, ok = h.wishSessions.AddRound(tenant, session.ID, wishReq.Feedback, candidates)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Wish session not found or expired"})
+	}
+	log.Printf("Regenerated wish session %s (round %d)", session.ID, len(session.Rounds))
+
+	return c.Status(200).JSON(wishSessionResponse(session))
+}
+
+// candidateCount applies the default to a requested number of candidates and
+// reports whether it is allowed
+func candidateCount(count int) (int, bool) {
+	if count == 0 {
+		return defaultWishCandidates, true
+	}
+	return count, count > 0 && count <= maxWishCandidates
+}
+
+// wishSessionResponse shows a session with its latest candidates first
+func wishSessionResponse(session wishsession.Session) fiber.Map {
+	rounds := make([]fiber.Map, len(session.Rounds))
+	for i, round := range session.Rounds {
+		rounds[i] = fiber.Map{
+			"candidates": wishList(round.Candidates),
+			"created_at": round.CreatedAt,
+		}
+		if round.Feedback != "" {
+			rounds[i]["feedback"] = round.Feedback
+		}
+	}
+
+	req := session.Request
+	response := fiber.Map{
+		"id":         session.ID,
+		"name":       req.Name,
+		"tone":       req.Tone,
+		"max_words":  req.MaxWords,
+		"candidates": rounds[len(rounds)-1]["candidates"],
+		"rounds":     rounds,
+		"expires_at": session.ExpiresAt,
+	}
+	if req.Language != "" {
+		response["language"] = req.Language
+	}
+	if req.Relationship != "" {
+		response["relationship"] = req.Relationship
+	}
+	if req.Age > 0 {
+		response["age"] = req.Age
+	}
+	return response
+}
+
+func wishList(wishes []clients.Wish) []fiber.Map {
+	list := make([]fiber.Map, len(wishes))
+	for i, wish := range wishes {
+		list[i] = fiber.Map{"wish": wish.Text, "source": wish.Source}
+	}
+	return list
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"hazel_ai/internal/clients"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// draftWriter is a wish provider that numbers its drafts and keeps the
+// requests it was sent
+type draftWriter struct {
+	mu       sync.Mutex
+	drafts   int
+	requests []clients.WishRequest
+}
+
+func (d *draftWriter) Name() string { return "drafts" }
+
+func (d *draftWriter) GenerateWish(ctx context.Context, req clients.WishRequest) (clients.Wish, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drafts++
+	d.requests = append(d.requests, req)
+	return clients.Wish{Text: fmt.Sprintf("Happy birthday, %s! (draft %d)", req.Name, d.drafts), Source: "drafts"}, nil
+}
+
+func (d *draftWriter) lastRequest() clients.WishRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.requests[len(d.requests)-1]
+}
+
+// wishResponse is the part of a wish or wish session response the tests check
+type wishResponse struct {
+	ID         string `json:"id"`
+	Wish       string `json:"wish"`
+	Error      string `json:"error"`
+	Candidates []struct {
+		Wish   string `json:"wish"`
+		Source string `json:"source"`
+	} `json:"candidates"`
+	Rounds []struct {
+		Feedback string `json:"feedback"`
+	} `json:"rounds"`
+}
+
+func TestGenerateWishCount(t *testing.T) {
+	s := newTestServer(t)
+	s.handler.wishes = &draftWriter{}
+	tests := []struct {
+		body           string
+		status         int
+		wantCandidates int
+	}{
+		{`{"name": "Alice"}`, 200, 0},
+		{`{"name": "Alice", "count": 1}`, 200, 1},
+		{`{"name": "Alice", "count": 3}`, 200, 3},
+		{`{"name": "Alice", "count": 6}`, 400, 0},
+		{`{"name": "Alice", "count": -1}`, 400, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			var resp wishResponse
+			if status := s.rest(t, "POST", "/api/wishes/generate", tt.body, &resp); status != tt.status {
+				t.Fatalf("status = %d (%s), want %d", status, resp.Error, tt.status)
+			}
+			if tt.status != 200 {
+				return
+			}
+			if resp.Wish == "" {
+				t.Error("response has no wish")
+			}
+			if len(resp.Candidates) != tt.wantCandidates {
+				t.Errorf("got %d candidates, want %d", len(resp.Candidates), tt.wantCandidates)
+			}
+			seen := map[string]bool{}
+			for _, c := range resp.Candidates {
+				if seen[c.Wish] {
+					t.Errorf("candidate repeated: %q", c.Wish)
+				}
+				seen[c.Wish] = true
+			}
+		})
+	}
+}
+
+func TestWishSessions(t *testing.T) {
+	s := newTestServer(t)
+	writer := &draftWriter{}
+	s.handler.wishes = writer
+
+	var created wishResponse
+	if status := s.rest(t, "POST", "/api/wishes/sessions", `{"name": "Alice", "count": 2}`, &created); status != 201 {
+		t.Fatalf("create status = %d (%s), want 201", status, created.Error)
+	}
+	if created.ID == "" || len(created.Candidates) != 2 || len(created.Rounds) != 1 {
+		t.Fatalf("created %+v, want an ID and one round of 2 candidates", created)
+	}
+
+	var regenerated wishResponse
+	path := fmt.Sprintf("/api/wishes/sessions/%s/regenerate", created.ID)
+	if status := s.rest(t, "POST", path, `{"feedback": "shorter", "count": 2}`, &regenerated); status != 200 {
+		t.Fatalf("regenerate status = %d (%s), want 200", status, regenerated.Error)
+	}
+	if len(regenerated.Rounds) != 2 || regenerated.Rounds[1].Feedback != "shorter" {
+		t.Errorf("rounds = %+v, want a second round with the feedback", regenerated.Rounds)
+	}
+	for _, c := range regenerated.Candidates {
+		for _, earlier := range created.Candidates {
+			if c.Wish == earlier.Wish {
+				t.Errorf("regenerated candidate repeats an earlier one: %q", c.Wish)
+			}
+		}
+	}
+	req := writer.lastRequest()
+	if req.Feedback != "shorter" {
+		t.Errorf("provider got feedback %q, want %q", req.Feedback, "shorter")
+	}
+	for _, earlier := range created.Candidates {
+		if !slices.Contains(req.Avoid, earlier.Wish) {
+			t.Errorf("provider wasn't asked to avoid %q", earlier.Wish)
+		}
+	}
+
+	var fetched wishResponse
+	if status := s.rest(t, "GET", "/api/wishes/sessions/"+created.ID, "", &fetched); status != 200 || len(fetched.Rounds) != 2 {
+		t.Errorf("get = %d with %d rounds, want 200 with 2", status, len(fetched.Rounds))
+	}
+
+	failures := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/api/wishes/sessions", `{"count": 2}`, 400},
+		{"POST", "/api/wishes/sessions", `{"name": "Alice", "count": 9}`, 400},
+		{"POST", "/api/wishes/sessions", `{"name": "Alice", "tone": "grumpy"}`, 400},
+		{"POST", path, `{"count": 0.5}`, 400},
+		{"POST", path, `{"count": 6}`, 400},
+		{"POST", "/api/wishes/sessions/missing/regenerate", `{}`, 404},
+		{"GET", "/api/wishes/sessions/missing", "", 404},
+	}
+	for _, tt := range failures {
+		if status := s.rest(t, tt.method, tt.path, tt.body, nil); status != tt.status {
+			t.Errorf("%s %s %s: status = %d, want %d", tt.method, tt.path, tt.body, status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package importer reads birthdays in bulk from the files people already
+// keep them in: CSV exports of a spreadsheet and vCard address books.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MaxRecords caps how many birthdays one file may hold
+const MaxRecords = 1000
+
+// bom is the byte order mark some spreadsheets write at the start of a file
+var bom = []byte("\ufeff")
+
+// Formats the importer reads
+const (
+	FormatCSV   = "csv"
+	FormatVCard = "vcard"
+)
+
+var (
+	// ErrUnknownFormat is returned for files that are neither CSV nor vCard
+	ErrUnknownFormat = errors.New("unknown file format: send a CSV or vCard file")
+	// ErrTooManyRecords is returned for files with more than MaxRecords birthdays
+	ErrTooManyRecords = fmt.Errorf("too many birthdays in one file (at most %d)", MaxRecords)
+)
+
+// Record is one birthday read from a file. Date is left as written, except
+// for vCard's compact forms which are turned into YYYY-MM-DD or MM-DD.
+type Record struct {
+	// Line is where the record starts in the file, for error messages
+	Line      int      `json:"line,omitempty"`
+	Name      string   `json:"name"`
+	Date      string   `json:"date"`
+	Interests []string `json:"interests,omitempty"`
+	Notes     string   `json:"notes,omitempty"`
+	HideYear  bool     `json:"hide_year,omitempty"`
+}
+
+// Detect names the format of a file from its MIME type, then its name, then
+// its content
+func Detect(name, mimeType string, data []byte) (string, error) {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	switch mimeType {
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	case "text/vcard", "text/x-vcard", "text/directory":
+		return FormatVCard, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".vcf", ".vcard":
+		return FormatVCard, nil
+	}
+
+	head := strings.ToUpper(string(bytes.TrimSpace(bytes.TrimPrefix(data, bom))))
+	if strings.HasPrefix(head, "BEGIN:VCARD") {
+		return FormatVCard, nil
+	}
+	if mimeType == "" || mimeType == "text/plain" {
+		if row, err := csv.NewReader(bytes.NewReader(data)).Read(); err == nil && len(row) >= 2 {
+			return FormatCSV, nil
+		}
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse reads the birthdays in a file of the given format
+func Parse(format string, data []byte) ([]Record, error) {
+	data = bytes.TrimPrefix(data, bom)
+	switch format {
+	case FormatCSV:
+		return ParseCSV(bytes.NewReader(data))
+	case FormatVCard:
+		return ParseVCard(bytes.NewReader(data))
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// csvColumns are the header names read for each field, in lowercase
+var csvColumns = map[string][]string{
+	"name":      {"name", "full name", "fullname", "person"},
+	"date":      {"date", "birthday", "birthdate", "birth date", "dob", "date of birth"},
+	"interests": {"interests", "hobbies"},
+	"notes":     {"notes", "note"},
+	"hide_year": {"hide_year", "hide year", "hide age"},
+}
+
+// ParseCSV reads a CSV file. A header row naming a name and a date column
+// (e.g. "name,birthday,interests,notes") may come first; without one the
+// first two columns are the name and the date. Interests are separated by
+// semicolons, and a hide_year column of "yes" or "true" keeps the year
+// private. Blank rows are skipped.
+func ParseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// csv.Reader skips blank lines, so each row's file line is kept for
+	// error messages
+	var rows [][]string
+	var lines []int
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		number, _ := reader.FieldPos(0)
+		rows, lines = append(rows, row), append(lines, number)
+	}
+
+	columns := map[string]int{"name": 0, "date": 1, "interests": -1, "notes": -1, "hide_year": -1}
+	start := 0
+	if len(rows) > 0 {
+		if header, ok := csvHeader(rows[0]); ok {
+			columns, start = header, 1
+		}
+	}
+
+	var records []Record
+	for i := start; i < len(rows); i++ {
+		row := rows[i]
+		field := func(name string) string {
+			if col := columns[name]; col >= 0 && col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		record := Record{Line: lines[i], Name: field("name"), Date: field("date"), Notes: field("notes")}
+		switch strings.ToLower(field("hide_year")) {
+		case "yes", "true", "y", "1":
+			record.HideYear = true
+		}
+		for _, interest := range strings.Split(field("interests"), ";") {
+			if interest = strings.TrimSpace(interest); interest != "" {
+				record.Interests = append(record.Interests, interest)
+			}
+		}
+		if len(records) == MaxRecords {
+			return nil, ErrTooManyRecords
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvHeader reads the column of each field from a header row. It reports
+// false when the row isn't a header naming both a name and a date column.
+func csvHeader(row []string) (map[string]int, bool) {
+	columns := map[string]int{"name": -1, "date": -1, "interests": -1, "notes": -1, "hide_year": -1}
+	for i, cell := range row {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		for field, names := range csvColumns {
+			for _, name := range names {
+				if cell == name && columns[field] < 0 {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	return columns, columns["name"] >= 0 && columns["date"] >= 0
+}
+
+// vCard BDAY values: 19900412, 1990-04-12, --0412 and --04-12
+var (
+	vcardDate     = regexp.MustCompile(`^(\d{4})-?(\d{2})-?(\d{2})`)
+	vcardYearless = regexp.MustCompile(`^--(\d{2})-?(\d{2})`)
+)
+
+// ParseVCard reads the contacts of a vCard file (versions 2.1 to 4.0) that
+// have a birthday. The name comes from FN, or N when there is no FN.
+// Contacts without a BDAY are skipped.
+func ParseVCard(r io.Reader) ([]Record, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vCard: %w", err)
+	}
+
+	var records []Record
+	var card *Record
+	var structuredName string
+	for _, l := range lines {
+		property, value, ok := strings.Cut(l.text, ":")
+		if !ok {
+			continue
+		}
+		// Drop parameters (BDAY;VALUE=date) and groups (item1.BDAY)
+		property, _, _ = strings.Cut(strings.ToUpper(property), ";")
+		if i := strings.LastIndex(property, "."); i >= 0 {
+			property = property[i+1:]
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case property == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			card, structuredName = &Record{Line: l.number}, ""
+		case card == nil:
+			continue
+		case property == "FN":
+			card.Name = unescape(value)
+		case property == "N":
+			structuredName = vcardName(value)
+		case property == "BDAY":
+			card.Date = vcardBirthday(value)
+		case property == "NOTE":
+			card.Notes = unescape(value)
+		case property == "END" && strings.EqualFold(value, "VCARD"):
+			if card.Name == "" {
+				card.Name = structuredName
+			}
+			if card.Date != "" {
+				if len(records) == MaxRecords {
+					return nil, ErrTooManyRecords
+				}
+				records = append(records, *card)
+			}
+			card = nil
+		}
+	}
+	return records, nil
+}
+
+// line is an unfolded vCard line and the file line it starts on
+type line struct {
+	number int
+	text   string
+}
+
+// unfold joins vCard lines continued on the next line with a leading space
+// or tab
+func unfold(r io.Reader) ([]line, error) {
+	var lines []line
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, line{number: number, text: text})
+	}
+	return lines, scanner.Err()
+}
+
+// vcardBirthday turns a BDAY value into YYYY-MM-DD or MM-DD. Values in other
+// forms, such as free text, are returned as they are.
+func vcardBirthday(value string) string {
+	if m := vcardYearless.FindStringSubmatch(value); m != nil {
+		return m[1] + "-" + m[2]
+	}
+	if m := vcardDate.FindStringSubmatch(value); m != nil {
+		return m[1] + "-" + m[2] + "-" + m[3]
+	}
+	return value
+}
+
+// vcardName turns an N value (family;given;additional;prefix;suffix) into
+// "Given Family"
+func vcardName(value string) string {
+	parts := strings.Split(value, ";")
+	var words []string
+	for _, i := range []int{1, 0} {
+		if i < len(parts) && strings.TrimSpace(parts[i]) != "" {
+			words = append(words, unescape(strings.TrimSpace(parts[i])))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// unescape undoes vCard text escaping
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
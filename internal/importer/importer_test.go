@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		want   []Record
+	}{
+		{"birthdays.csv", FormatCSV, []Record{
+			{Line: 2, Name: "Alice Smith", Date: "1990-04-12", Interests: []string{"chess", "hiking"}, Notes: "Met at PyCon", HideYear: true},
+			{Line: 3, Name: "Doe, Jane", Date: "12/25"},
+			{Line: 5, Name: "Bob", Date: "March 3", Notes: "likes \"dad\"\njokes"},
+			// Rows without a name or a date are kept so the import can report
+			// them against their line, counted past blank and multi-line rows
+			{Line: 7, Name: "Carol"},
+			{Line: 8, Date: "1985-07-01"},
+			{Line: 9, Name: "Dave", Date: "31/02/1990"},
+		}},
+		{"headerless.csv", FormatCSV, []Record{
+			{Line: 1, Name: "Alice", Date: "1990-04-12"},
+			{Line: 2, Name: "Bob", Date: "03-03"},
+			{Line: 3, Name: "Carol"},
+		}},
+		{"contacts.vcf", FormatVCard, []Record{
+			{Line: 1, Name: "Alice Smith", Date: "1990-04-12", Notes: "Loves hiking, chess and long walks and tea"},
+			{Line: 9, Name: "Bob", Date: "03-03"},
+			{Line: 19, Name: "Carol Brown", Date: "1985-12-01"},
+			{Line: 24, Name: "Dave Jones", Date: "12-25"},
+			{Line: 30, Name: "Erin", Date: "circa 1970"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data := readFixture(t, tt.file)
+			format, err := Detect(tt.file, "", data)
+			if err != nil || format != tt.format {
+				t.Fatalf("Detect = %q, %v; want %q", format, err, tt.format)
+			}
+
+			got, err := Parse(format, data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d records, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("record %d = %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVUnbalancedQuotes(t *testing.T) {
+	_, err := Parse(FormatCSV, readFixture(t, "unbalanced_quotes.csv"))
+	if err == nil || !strings.Contains(err.Error(), "failed to read CSV") {
+		t.Errorf("error = %v, want a CSV read error", err)
+	}
+}
+
+func TestVCardBirthday(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"19900412", "1990-04-12"},
+		{"1990-04-12", "1990-04-12"},
+		{"1990-04-12T00:00:00Z", "1990-04-12"},
+		{"--0412", "04-12"},
+		{"--04-12", "04-12"},
+		{"April 12", "April 12"},
+	}
+	for _, tt := range tests {
+		if got := vcardBirthday(tt.value); got != tt.want {
+			t.Errorf("vcardBirthday(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		mimeType string
+		data     string
+		want     string
+		wantErr  error
+	}{
+		{"CSV MIME type", "people.txt", "text/csv; charset=utf-8", "", FormatCSV, nil},
+		{"vCard MIME type", "people.txt", "text/x-vcard", "", FormatVCard, nil},
+		{"vcf extension", "Contacts.VCF", "application/octet-stream", "", FormatVCard, nil},
+		{"vCard content after a BOM", "", "", "\ufeff\r\nBEGIN:VCARD\r\nEND:VCARD\r\n", FormatVCard, nil},
+		{"CSV content", "", "text/plain", "Alice,1990-04-12\n", FormatCSV, nil},
+		{"one column of text", "", "text/plain", "hello there\n", "", ErrUnknownFormat},
+		{"other MIME type", "", "image/png", "Alice,1990-04-12\n", "", ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.fileName, tt.mimeType, []byte(tt.data))
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Detect = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTooManyRecords(t *testing.T) {
+	var csv, vcard strings.Builder
+	for range MaxRecords + 1 {
+		csv.WriteString("Alice,1990-04-12\n")
+		vcard.WriteString("BEGIN:VCARD\nFN:Alice\nBDAY:--0412\nEND:VCARD\n")
+	}
+
+	for format, data := range map[string]string{FormatCSV: csv.String(), FormatVCard: vcard.String()} {
+		if _, err := Parse(format, []byte(data)); !errors.Is(err, ErrTooManyRecords) {
+			t.Errorf("%s: error = %v, want ErrTooManyRecords", format, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package intent routes chat messages to the thing Hazel should do with
+// them. Each intent scores how sure it is that a message is meant for it;
+// the router picks the most confident one and notices when two are too
+// close to call.
+package intent
+
+import (
+	"context"
+	"hazel_ai/internal/a2a/protocol"
+	"hazel_ai/internal/dateparse"
+	"sort"
+	"strings"
+)
+
+// Reply is what Hazel answers a message with
+type Reply struct {
+	Text string
+	// Failed marks a reply reporting an error, so the A2A task ends failed
+	Failed bool
+	// Data is the machine-readable result, sent as a data part next to the
+	// text
+	Data map[string]any
+}
+
+// Entities are the things recognised in a message before it is routed
+type Entities struct {
+	// Date is the first date in the message, if any
+	Date *dateparse.Date
+	// DateText is the text the date (or impossible date) was read from
+	DateText string
+	// DateErr is set when the message contains an impossible date such as 02-30
+	DateErr error
+	// Names are the people the message is about
+	Names []string
+	// Self is set when the sender talks about themselves ("my birthday")
+	Self bool
+}
+
+// HasDate reports whether the message contains a date, even an impossible one
+func (e Entities) HasDate() bool {
+	return e.Date != nil || e.DateErr != nil
+}
+
+// Message is a chat message ready to be routed
+type Message struct {
+	// Text is the message as typed; Lower is trimmed and lowercased
+	Text  string
+	Lower string
+	Entities
+	// Params are the A2A message/send params the message arrived in
+	Params protocol.MessageSendParams
+	// Tenant is the birthday book the message may read and change
+	Tenant string
+	// ConversationKey identifies the conversation for follow-up questions,
+	// or is empty when follow-ups aren't possible
+	ConversationKey string
+	// Context is canceled when the A2A task handling the message is
+	Context context.Context
+	// Partial, when set, is given the reply's text piece by piece while it
+	// is being written, e.g. a wish streaming from Gemini
+	Partial func(text string)
+}
+
+// TextWithoutDate is the message with the date text taken out, so month
+// names aren't mistaken for people
+func (m Message) TextWithoutDate() string {
+	if m.DateText == "" {
+		return m.Text
+	}
+	return strings.Replace(m.Text, m.DateText, " ", 1)
+}
+
+// Intent is one thing Hazel can do in chat
+type Intent interface {
+	// Name identifies the intent, e.g. in the agent card
+	Name() string
+	// Description says what the intent does, for help text and the agent card
+	Description() string
+	// Examples are messages the intent handles
+	Examples() []string
+	// Score returns how confident the intent is that it should handle the
+	// message, from 0 (not at all) to 1 (certain)
+	Score(m Message) float64
+	// Handle acts on the message and returns the reply
+	Handle(m Message) Reply
+}
+
+// Func is an Intent built from plain values and functions
+type Func struct {
+	ID         string
+	Summary    string
+	Samples    []string
+	ScoreFunc  func(m Message) float64
+	HandleFunc func(m Message) Reply
+}
+
+func (f Func) Name() string            { return f.ID }
+func (f Func) Description() string     { return f.Summary }
+func (f Func) Examples() []string      { return f.Samples }
+func (f Func) Score(m Message) float64 { return f.ScoreFunc(m) }
+func (f Func) Handle(m Message) Reply  { return f.HandleFunc(m) }
+
+// Default thresholds for a Router
+const (
+	// DefaultThreshold is the lowest score an intent can be chosen with
+	DefaultThreshold = 0.4
+	// DefaultMargin is how far ahead the best intent must be of the
+	// runner-up for the choice not to be ambiguous
+	DefaultMargin = 0.1
+)
+
+// Match is an intent with the score it gave a message
+type Match struct {
+	Intent Intent
+	Score  float64
+}
+
+// Result is the outcome of routing a message
+type Result struct {
+	// Best is the chosen intent, or nil when none was confident enough or
+	// the choice was ambiguous
+	Best Intent
+	// Candidates are the intents that scored above the threshold, best first.
+	// When Best is nil and there are several, the message was ambiguous.
+	Candidates []Match
+}
+
+// Ambiguous reports whether several intents were too close to choose between
+func (r Result) Ambiguous() bool {
+	return r.Best == nil && len(r.Candidates) > 1
+}
+
+// Router holds the registered intents
+type Router struct {
+	intents   []Intent
+	Threshold float64
+	Margin    float64
+}
+
+// NewRouter creates a router with the default threshold and margin
+func NewRouter(intents ...Intent) *Router {
+	return &Router{intents: intents, Threshold: DefaultThreshold, Margin: DefaultMargin}
+}
+
+// Register adds an intent. Registration order breaks ties between equal scores.
+func (r *Router) Register(i Intent) {
+	r.intents = append(r.intents, i)
+}
+
+// Intents returns the registered intents in registration order
+func (r *Router) Intents() []Intent {
+	return append([]Intent(nil), r.intents...)
+}
+
+// Route scores the message against every intent and picks the best one
+func (r *Router) Route(m Message) Result {
+	var candidates []Match
+	for _, i := range r.intents {
+		if score := i.Score(m); score >= r.Threshold {
+			candidates = append(candidates, Match{Intent: i, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].Score > candidates[b].Score
+	})
+
+	result := Result{Candidates: candidates}
+	switch {
+	case len(candidates) == 0:
+	case len(candidates) == 1 || candidates[0].Score-candidates[1].Score >= r.Margin:
+		result.Best = candidates[0].Intent
+	default:
+		// Keep only the intents too close to the best to rule out
+		close := candidates[:1]
+		for _, c := range candidates[1:] {
+			if candidates[0].Score-c.Score < r.Margin {
+				close = append(close, c)
+			}
+		}
+		result.Candidates = close
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"0 8 * *",
+		"0 8 * * * *",
+		"60 8 * * *",
+		"0 24 * * *",
+		"0 8 0 * *",
+		"0 8 * 13 *",
+		"0 8 * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, spec := range tests {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"daily later today", "0 8 * * *", at(2025, 3, 10, 7, 59), at(2025, 3, 10, 8, 0)},
+		{"daily at the exact time moves on", "0 8 * * *", at(2025, 3, 10, 8, 0), at(2025, 3, 11, 8, 0)},
+		{"daily across year end", "0 8 * * *", at(2025, 12, 31, 9, 0), at(2026, 1, 1, 8, 0)},
+		{"seconds are ignored", "30 * * * *", time.Date(2025, 3, 10, 7, 29, 59, 0, time.UTC), at(2025, 3, 10, 7, 30)},
+		{"step minutes", "*/15 * * * *", at(2025, 3, 10, 7, 16), at(2025, 3, 10, 7, 30)},
+		{"list of hours", "0 6,18 * * *", at(2025, 3, 10, 7, 0), at(2025, 3, 10, 18, 0)},
+		{"hour range", "0 9-17 * * *", at(2025, 3, 10, 17, 30), at(2025, 3, 11, 9, 0)},
+		{"weekdays only", "0 8 * * 1-5", at(2025, 3, 14, 9, 0), at(2025, 3, 17, 8, 0)},
+		{"sunday as 7", "0 0 * * 7", at(2025, 3, 10, 0, 0), at(2025, 3, 16, 0, 0)},
+		{"dom or dow when both set", "0 0 1 * 1", at(2025, 3, 25, 0, 0), at(2025, 3, 31, 0, 0)},
+		{"dom and star dow", "0 0 1 * *", at(2025, 3, 25, 0, 0), at(2025, 4, 1, 0, 0)},
+		{"leap day", "0 0 29 2 *", at(2025, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"yearly descriptor", "@yearly", at(2025, 6, 1, 0, 0), at(2026, 1, 1, 0, 0)},
+		{"hourly descriptor", "@hourly", at(2025, 6, 1, 10, 5), at(2025, 6, 1, 11, 0)},
+		{"never matches", "0 0 30 2 *", at(2025, 1, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
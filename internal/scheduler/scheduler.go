@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so the scheduler can be driven by a fake clock in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the wall clock
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Job is a named piece of work that runs on a cron schedule
+type Job struct {
+	Name     string
+	Spec     string
+	Schedule *Schedule
+	Run      func(ctx context.Context)
+
+	next time.Time
+}
+
+// Scheduler runs jobs when their cron schedules come due
+type Scheduler struct {
+	clock Clock
+
+	mu      sync.Mutex
+	jobs    []*Job
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running sync.WaitGroup
+}
+
+// New creates a scheduler. A nil clock means the wall clock.
+func New(clock Clock) *Scheduler {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Scheduler{clock: clock}
+}
+
+// Add registers a job. It must be called before Start.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context)) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &Job{Name: name, Spec: spec, Schedule: schedule, Run: run})
+	return nil
+}
+
+// Jobs returns the registered jobs with their next run time
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, *j)
+	}
+	return jobs
+}
+
+// Start begins running jobs in the background
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	now := s.clock.Now()
+	for _, j := range s.jobs {
+		j.next = j.Schedule.Next(now)
+		log.Printf("⏰ Scheduled job %s (%s), next run at %s", j.Name, j.Spec, j.next.Format(time.RFC3339))
+	}
+
+	go s.loop(ctx)
+}
+
+// Stop halts the scheduler and waits for any running jobs to finish.
+// Running jobs see their context canceled.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+	s.running.Wait()
+	log.Println("Scheduler stopped")
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		next := s.nextRun()
+		if next.IsZero() {
+			// Nothing left to run; wait for shutdown
+			<-ctx.Done()
+			return
+		}
+
+		wait := next.Sub(s.clock.Now())
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(wait):
+			s.runDue(ctx, s.clock.Now())
+		}
+	}
+}
+
+func (s *Scheduler) nextRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, j := range s.jobs {
+		if j.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || j.next.Before(next) {
+			next = j.next
+		}
+	}
+	return next
+}
+
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.next.IsZero() || j.next.After(now) {
+			continue
+		}
+
+		j.next = j.Schedule.Next(now)
+
+		job := j
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			log.Printf("⏰ Running job %s", job.Name)
+			job.Run(ctx)
+		}()
+	}
+}
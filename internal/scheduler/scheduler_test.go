@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- at
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: at, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires every timer that is now due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// waitForTimer blocks until the scheduler is waiting on the clock
+func (c *fakeClock) waitForTimer(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("scheduler never waited on the clock")
+}
+
+func TestSchedulerRunsDueJobs(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 10, 7, 58, 0, 0, time.UTC))
+	s := New(clock)
+
+	runs := make(chan string, 4)
+	if err := s.Add("today", "0 8 * * *", func(ctx context.Context) { runs <- "today" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("tomorrow", "0 20 * * *", func(ctx context.Context) { runs <- "tomorrow" }); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	for _, j := range s.Jobs() {
+		want := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+		if j.Name == "tomorrow" {
+			want = time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+		}
+		if !j.next.Equal(want) {
+			t.Errorf("job %s next = %s, want %s", j.Name, j.next, want)
+		}
+	}
+
+	clock.waitForTimer(t)
+	clock.Advance(time.Minute)
+	select {
+	case name := <-runs:
+		t.Fatalf("job %s ran before it was due", name)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.waitForTimer(t)
+	clock.Advance(time.Minute)
+	select {
+	case name := <-runs:
+		if name != "today" {
+			t.Fatalf("ran %s, want today", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("today job did not run at 08:00")
+	}
+
+	clock.waitForTimer(t)
+	for _, j := range s.Jobs() {
+		if j.Name != "today" {
+			continue
+		}
+		want := time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)
+		if !j.next.Equal(want) {
+			t.Errorf("after running, next = %s, want %s", j.next, want)
+		}
+	}
+
+	select {
+	case name := <-runs:
+		t.Fatalf("job %s ran unexpectedly", name)
+	default:
+	}
+}
+
+func TestSchedulerStopWaitsForRunningJobs(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 10, 7, 59, 0, 0, time.UTC))
+	s := New(clock)
+
+	started := make(chan struct{})
+	var canceled bool
+	err := s.Add("slow", "0 8 * * *", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		canceled = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	clock.waitForTimer(t)
+	clock.Advance(time.Minute)
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+	if !canceled {
+		t.Error("Stop returned before the running job saw its context canceled")
+	}
+
+	// A second Stop is a no-op
+	s.Stop()
+}
+
+func TestSchedulerStopWithoutJobs(t *testing.T) {
+	s := New(newFakeClock(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)))
+	s.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return for an idle scheduler")
+	}
+}
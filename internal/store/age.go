@@ -0,0 +1,15 @@
+package store
+
+// Public returns the birthday as it may be shown to others: the birth year
+// is cleared when the person asked to hide it
+func (b Birthday) Public() Birthday {
+	if b.HideYear {
+		b.Year = 0
+	}
+	return b
+}
+
+// KnowsAge reports whether an age can be computed and shown for b
+func (b Birthday) KnowsAge() bool {
+	return b.Year > 0 && !b.HideYear
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CorruptFileError is returned when a data file exists but cannot be parsed
+type CorruptFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptFileError) Error() string {
+	return fmt.Sprintf("%s is corrupt (%v); set HAZEL_STORE_RECOVER=true to restore the last good backup", e.Path, e.Err)
+}
+
+func (e *CorruptFileError) Unwrap() error {
+	return e.Err
+}
+
+// backupPath returns the path of the n-th most recent backup (1 is the newest)
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// writeFileAtomic replaces path with data without ever leaving a partially
+// written file behind: data goes to a temp file in the same directory, is
+// fsynced and then renamed over the original. Before the rename the current
+// file is rotated into up to `backups` numbered backups.
+func writeFileAtomic(path string, data []byte, backups int) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmpName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmpName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpName, err)
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", tmpName, err)
+	}
+
+	if backups > 0 {
+		if err := rotateBackups(path, backups); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", path, err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// rotateBackups shifts path.bak.1..n-1 up by one and copies the current file
+// into path.bak.1. The current file is copied, not moved, so a crash at any
+// point still leaves a complete file at path.
+func rotateBackups(path string, backups int) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	os.Remove(backupPath(path, backups))
+	for i := backups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate backup %s: %w", backupPath(path, i), err)
+		}
+	}
+
+	if err := copyFile(path, backupPath(path, 1)); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir fsyncs a directory so a rename inside it survives a crash. Not
+// every platform supports this, and the rename has already happened, so
+// failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	if err := writeFileAtomic(path, []byte("v1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "v1" {
+		t.Errorf("file = %q, want v1", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf("permissions = %o, want 644", perm)
+	}
+
+	if err := writeFileAtomic(path, []byte("v2"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "v2" {
+		t.Errorf("file = %q, want v2", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only data.json (no temp files or backups)", names)
+	}
+}
+
+func TestWriteFileAtomicRotatesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		if err := writeFileAtomic(path, []byte(v), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		path:                "v4",
+		backupPath(path, 1): "v3",
+		backupPath(path, 2): "v2",
+	}
+	for p, v := range want {
+		if got := readFile(t, p); got != v {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), got, v)
+		}
+	}
+	if _, err := os.Stat(backupPath(path, 3)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s exists, want at most 2 backups", backupPath(path, 3))
+	}
+}
+
+func TestWriteFileAtomicFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	if err := writeFileAtomic(path, []byte("good"), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in the way of the backup makes the rotation fail
+	if err := os.Mkdir(backupPath(path, 1), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupPath(path, 1), "keep"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 1); err == nil {
+		t.Fatal("write succeeded, want an error from the backup rotation")
+	}
+	if got := readFile(t, path); got != "good" {
+		t.Errorf("file = %q after a failed write, want the original", got)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "data.json")
+	if err := writeFileAtomic(path, []byte("v1"), 1); err == nil {
+		t.Error("write into a missing directory succeeded, want an error")
+	}
+}
+
+const goodStore = `{"a": {"id": "a", "name": "Ada", "month": 12, "day": 10, "created_at": "2025-01-01T00:00:00Z"}}`
+
+func TestLoadCorruptStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		backups map[int]string
+		opts    Options
+		wantErr bool
+		want    string
+	}{
+		{
+			name:    "refuses without recovery",
+			backups: map[int]string{1: goodStore},
+			opts:    Options{Backups: 2},
+			wantErr: true,
+		},
+		{
+			name:    "restores the newest backup",
+			backups: map[int]string{1: goodStore},
+			opts:    Options{Backups: 2, RecoverFromBackup: true},
+			want:    "Ada",
+		},
+		{
+			name:    "skips an unreadable backup",
+			backups: map[int]string{1: "{broken", 2: goodStore},
+			opts:    Options{Backups: 2, RecoverFromBackup: true},
+			want:    "Ada",
+		},
+		{
+			name:    "fails without a usable backup",
+			backups: map[int]string{1: "{broken"},
+			opts:    Options{Backups: 2, RecoverFromBackup: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "birthdays.json")
+			if err := os.WriteFile(path, []byte(`{"a": {"id": `), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for n, data := range tt.backups {
+				if err := os.WriteFile(backupPath(path, n), []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bs, err := NewBirthdayStore(path, tt.opts)
+			if tt.wantErr {
+				var corrupt *CorruptFileError
+				if !errors.As(err, &corrupt) {
+					t.Fatalf("err = %v, want a *CorruptFileError", err)
+				}
+				if corrupt.Path != path {
+					t.Errorf("corrupt path = %s, want %s", corrupt.Path, path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := bs.Get(DefaultTenant, "a")
+			if err != nil || b.Name != tt.want {
+				t.Fatalf("Get = %+v, %v; want %s", b, err, tt.want)
+			}
+
+			// The restored data is back on disk and the corrupt file is kept
+			if _, err := decodeBirthdays([]byte(readFile(t, path))); err != nil {
+				t.Errorf("restored file does not parse: %v", err)
+			}
+			quarantined, _ := filepath.Glob(path + ".corrupt-*")
+			if len(quarantined) != 1 {
+				t.Errorf("found %d quarantined files, want 1", len(quarantined))
+			}
+		})
+	}
+}
+
+func TestLoadEmptyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "birthdays.json")
+	if err := os.WriteFile(path, []byte("\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := NewBirthdayStore(path, Options{})
+	if err != nil {
+		t.Fatalf("empty file: %v", err)
+	}
+	if list, _ := bs.List(AllTenants); len(list) != 0 {
+		t.Errorf("empty file loaded %d birthdays", len(list))
+	}
+}
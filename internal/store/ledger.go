@@ -0,0 +1,136 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeliveryRecord marks that a reminder of a given kind went out for a
+// birthday in a given year
+type DeliveryRecord struct {
+	BirthdayID string    `json:"birthday_id"`
+	Year       int       `json:"year"`
+	Kind       string    `json:"kind"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Ledger remembers which reminders have already been delivered so they are
+// never sent twice, even across restarts
+type Ledger struct {
+	mu       sync.Mutex
+	entries  map[string]DeliveryRecord
+	inFlight map[string]bool
+	file     string
+}
+
+// LedgerFileFor returns the ledger path that sits next to a birthday store file
+func LedgerFileFor(storeFile string) string {
+	return filepath.Join(filepath.Dir(storeFile), "deliveries.json")
+}
+
+// NewLedger loads the ledger kept in filename, starting empty when it doesn't
+// exist yet. A malformed file is a *CorruptFileError: starting empty would
+// send every reminder in it again.
+func NewLedger(filename string) (*Ledger, error) {
+	ledger := &Ledger{
+		entries:  make(map[string]DeliveryRecord),
+		inFlight: make(map[string]bool),
+		file:     filename,
+	}
+	if err := ledger.load(); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+func ledgerKey(birthdayID string, year int, kind string) string {
+	return fmt.Sprintf("%s|%d|%s", birthdayID, year, kind)
+}
+
+// Sent reports whether the reminder has already been delivered
+func (l *Ledger) Sent(birthdayID string, year int, kind string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.entries[ledgerKey(birthdayID, year, kind)]
+	return ok
+}
+
+// Claim reserves a reminder for sending. It returns false if the reminder was
+// already delivered or another run is sending it right now. A successful
+// claim must be followed by Confirm or Release.
+func (l *Ledger) Claim(birthdayID string, year int, kind string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := ledgerKey(birthdayID, year, kind)
+	if _, ok := l.entries[key]; ok || l.inFlight[key] {
+		return false
+	}
+	l.inFlight[key] = true
+	return true
+}
+
+// Confirm records a claimed reminder as delivered and persists the ledger
+func (l *Ledger) Confirm(birthdayID string, year int, kind string, sentAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := ledgerKey(birthdayID, year, kind)
+	delete(l.inFlight, key)
+	l.entries[key] = DeliveryRecord{
+		BirthdayID: birthdayID,
+		Year:       year,
+		Kind:       kind,
+		SentAt:     sentAt,
+	}
+	return l.save()
+}
+
+// Release drops a claim after a failed delivery so it can be retried later
+func (l *Ledger) Release(birthdayID string, year int, kind string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.inFlight, ledgerKey(birthdayID, year, kind))
+}
+
+// save writes the ledger to disk. The caller must hold l.mu.
+func (l *Ledger) save() error {
+	records := make([]DeliveryRecord, 0, len(l.entries))
+	for _, r := range l.entries {
+		records = append(records, r)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery ledger: %w", err)
+	}
+	if err := writeFileAtomic(l.file, data, 0); err != nil {
+		return fmt.Errorf("failed to write delivery ledger: %w", err)
+	}
+	return nil
+}
+
+func (l *Ledger) load() error {
+	data, err := os.ReadFile(l.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read delivery ledger: %w", err)
+	}
+
+	var records []DeliveryRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return &CorruptFileError{Path: l.file, Err: err}
+	}
+	for _, r := range records {
+		l.entries[ledgerKey(r.BirthdayID, r.Year, r.Kind)] = r
+	}
+	return nil
+}
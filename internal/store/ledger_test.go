@@ -0,0 +1,73 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.json")
+	l, err := NewLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !l.Claim("alice", 2025, "today") {
+		t.Fatal("first claim refused")
+	}
+	if l.Claim("alice", 2025, "today") {
+		t.Error("second claim allowed while the first is in flight")
+	}
+	if err := l.Confirm("alice", 2025, "today", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed delivery can be claimed again
+	if !l.Claim("bob", 2025, "today") {
+		t.Fatal("claim for bob refused")
+	}
+	l.Release("bob", 2025, "today")
+
+	reloaded, err := NewLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Sent("alice", 2025, "today") || reloaded.Claim("alice", 2025, "today") {
+		t.Error("delivery to alice forgotten after reload")
+	}
+	if reloaded.Sent("alice", 2026, "today") || reloaded.Sent("alice", 2025, "tomorrow") {
+		t.Error("delivery counted for another year or kind")
+	}
+	if !reloaded.Claim("bob", 2025, "today") {
+		t.Error("released claim for bob still held after reload")
+	}
+}
+
+func TestLedgerMissingFile(t *testing.T) {
+	l, err := NewLedger(filepath.Join(t.TempDir(), "deliveries.json"))
+	if err != nil || l.Sent("alice", 2025, "today") {
+		t.Errorf("NewLedger = %v, want an empty ledger", err)
+	}
+}
+
+func TestLedgerCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.json")
+	corrupt := []byte(`[{"birthday_id": "alice", "year": 2025,`)
+	if err := os.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewLedger(path)
+	var corruptErr *CorruptFileError
+	if !errors.As(err, &corruptErr) || corruptErr.Path != path {
+		t.Fatalf("err = %v, want a *CorruptFileError for %s", err, path)
+	}
+
+	// The file is left as it was so the deliveries in it can be recovered
+	if data, _ := os.ReadFile(path); string(data) != string(corrupt) {
+		t.Errorf("corrupt ledger was rewritten: %q", data)
+	}
+}
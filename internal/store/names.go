@@ -0,0 +1,46 @@
+package store
+
+import (
+	"sort"
+	"strings"
+)
+
+// FindByName returns the tenant's birthdays for the named person. Exact
+// matches (ignoring case) win; failing that, entries whose first name
+// matches are returned, so "Alice" finds "Alice Smith".
+func FindByName(repo Repository, tenant, name string) ([]Birthday, error) {
+	birthdays, err := repo.List(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.Join(strings.Fields(name), " ")
+	var exact, first []Birthday
+	for _, b := range birthdays {
+		switch {
+		case strings.EqualFold(b.Name, name):
+			exact = append(exact, b)
+		case !strings.Contains(name, " ") && strings.EqualFold(firstName(b.Name), name):
+			first = append(first, b)
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = first
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Name != matches[j].Name {
+			return matches[i].Name < matches[j].Name
+		}
+		return matches[i].CreatedAt.Before(matches[j].CreatedAt)
+	})
+	return matches, nil
+}
+
+func firstName(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
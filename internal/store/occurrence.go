@@ -0,0 +1,150 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LeapDayPolicy decides when a February 29 birthday is celebrated in a
+// year that has no February 29
+type LeapDayPolicy int
+
+const (
+	// LeapDayFeb28 celebrates on February 28 (the default)
+	LeapDayFeb28 LeapDayPolicy = iota
+	// LeapDayMar1 celebrates on March 1
+	LeapDayMar1
+)
+
+// ParseLeapDayPolicy parses "feb28" or "mar1"
+func ParseLeapDayPolicy(s string) (LeapDayPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "feb28", "feb-28", "02-28":
+		return LeapDayFeb28, nil
+	case "mar1", "mar-1", "03-01":
+		return LeapDayMar1, nil
+	default:
+		return LeapDayFeb28, fmt.Errorf("unknown leap day policy %q (expected feb28 or mar1)", s)
+	}
+}
+
+func (p LeapDayPolicy) String() string {
+	if p == LeapDayMar1 {
+		return "mar1"
+	}
+	return "feb28"
+}
+
+// Calendar works out when birthdays are celebrated. It is the single place
+// that knows about leap days; every "today", "upcoming" and reminder lookup
+// goes through it.
+type Calendar struct {
+	LeapDay LeapDayPolicy
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// DateIn returns the day b is celebrated in the given year, at midnight in loc
+func (c Calendar) DateIn(b Birthday, year int, loc *time.Location) time.Time {
+	month, day := time.Month(b.Month), b.Day
+	if month == time.February && day == 29 && !isLeapYear(year) {
+		if c.LeapDay == LeapDayMar1 {
+			month, day = time.March, 1
+		} else {
+			day = 28
+		}
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// OccursOn reports whether b is celebrated on the given day
+func (c Calendar) OccursOn(b Birthday, day time.Time) bool {
+	date := c.DateIn(b, day.Year(), day.Location())
+	return date.Month() == day.Month() && date.Day() == day.Day()
+}
+
+// NextOccurrence returns the next day b is celebrated, counting today
+func (c Calendar) NextOccurrence(b Birthday, from time.Time) time.Time {
+	today := startOfDay(from)
+	next := c.DateIn(b, today.Year(), today.Location())
+	if next.Before(today) {
+		next = c.DateIn(b, today.Year()+1, today.Location())
+	}
+	return next
+}
+
+// DaysUntil returns the number of days until b is next celebrated (0 is today)
+func (c Calendar) DaysUntil(b Birthday, from time.Time) int {
+	today := startOfDay(from)
+	next := c.NextOccurrence(b, from)
+	// Count calendar days rather than dividing durations so DST changes don't
+	// shave a day off
+	return int(time.Date(next.Year(), next.Month(), next.Day(), 12, 0, 0, 0, time.UTC).
+		Sub(time.Date(today.Year(), today.Month(), today.Day(), 12, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// AgeOn returns how old the person is on the given day. The second result is
+// false when the birth year is unknown or hidden.
+func (c Calendar) AgeOn(b Birthday, day time.Time) (int, bool) {
+	if !b.KnowsAge() {
+		return 0, false
+	}
+
+	age := day.Year() - b.Year
+	if startOfDay(day).Before(c.DateIn(b, day.Year(), day.Location())) {
+		age--
+	}
+	if age < 0 {
+		return 0, false
+	}
+	return age, true
+}
+
+// TurningOn returns the age the person turns at their next birthday on or
+// after the given day (so on the birthday itself, the age they turn today)
+func (c Calendar) TurningOn(b Birthday, day time.Time) (int, bool) {
+	if !b.KnowsAge() {
+		return 0, false
+	}
+	return c.NextOccurrence(b, day).Year() - b.Year, true
+}
+
+// storedDatesFor returns the stored (month, day) pairs that may be celebrated
+// on the given day: the day itself plus February 29 when the policy moves it here
+func (c Calendar) storedDatesFor(day time.Time) [][2]int {
+	dates := [][2]int{{int(day.Month()), day.Day()}}
+	if isLeapYear(day.Year()) {
+		return dates
+	}
+
+	if (c.LeapDay == LeapDayFeb28 && day.Month() == time.February && day.Day() == 28) ||
+		(c.LeapDay == LeapDayMar1 && day.Month() == time.March && day.Day() == 1) {
+		dates = append(dates, [2]int{2, 29})
+	}
+	return dates
+}
+
+// BirthdaysOn returns the tenant's birthdays celebrated on the given day,
+// including leap-day birthdays moved there by the calendar's policy
+func BirthdaysOn(repo Repository, c Calendar, tenant string, day time.Time) ([]Birthday, error) {
+	var matches []Birthday
+	for _, md := range c.storedDatesFor(day) {
+		birthdays, err := repo.ListByDate(tenant, md[0], md[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range birthdays {
+			if c.OccursOn(b, day) {
+				matches = append(matches, b)
+			}
+		}
+	}
+	return matches, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
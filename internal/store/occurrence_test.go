@@ -0,0 +1,161 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 9, 30, 0, 0, time.UTC)
+}
+
+func TestCalendarLeapDay(t *testing.T) {
+	leapling := Birthday{Name: "Leap", Month: 2, Day: 29, Year: 2000}
+	feb28 := Calendar{LeapDay: LeapDayFeb28}
+	mar1 := Calendar{LeapDay: LeapDayMar1}
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		on       time.Time
+		want     bool
+	}{
+		{"feb28 policy, non-leap year, Feb 28", feb28, day(2025, 2, 28), true},
+		{"feb28 policy, non-leap year, Mar 1", feb28, day(2025, 3, 1), false},
+		{"feb28 policy, leap year, Feb 28", feb28, day(2024, 2, 28), false},
+		{"feb28 policy, leap year, Feb 29", feb28, day(2024, 2, 29), true},
+		{"mar1 policy, non-leap year, Feb 28", mar1, day(2025, 2, 28), false},
+		{"mar1 policy, non-leap year, Mar 1", mar1, day(2025, 3, 1), true},
+		{"mar1 policy, leap year, Feb 29", mar1, day(2024, 2, 29), true},
+		{"mar1 policy, leap year, Mar 1", mar1, day(2024, 3, 1), false},
+		{"century year is not a leap year", feb28, day(2100, 2, 28), true},
+		{"400th year is a leap year", feb28, day(2000, 2, 28), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.OccursOn(leapling, tt.on); got != tt.want {
+				t.Errorf("OccursOn(%s) = %v, want %v", tt.on.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarNextOccurrence(t *testing.T) {
+	leapling := Birthday{Name: "Leap", Month: 2, Day: 29, Year: 2000}
+	newYear := Birthday{Name: "Jan", Month: 1, Day: 1, Year: 1990}
+	newYearsEve := Birthday{Name: "Dec", Month: 12, Day: 31, Year: 1990}
+
+	tests := []struct {
+		name      string
+		calendar  Calendar
+		birthday  Birthday
+		from      time.Time
+		wantDate  time.Time
+		wantDays  int
+		wantAge   int
+		turningOn int
+	}{
+		{"Jan 1 seen from Dec 31", Calendar{}, newYear, day(2025, 12, 31), day(2026, 1, 1), 1, 35, 36},
+		{"Dec 31 seen from Jan 1", Calendar{}, newYearsEve, day(2026, 1, 1), day(2026, 12, 31), 364, 35, 36},
+		{"Dec 31 on the day", Calendar{}, newYearsEve, day(2025, 12, 31), day(2025, 12, 31), 0, 35, 35},
+		{"Jan 1 on the day", Calendar{}, newYear, day(2026, 1, 1), day(2026, 1, 1), 0, 36, 36},
+		{"leap day, feb28 policy, non-leap year", Calendar{LeapDay: LeapDayFeb28}, leapling, day(2025, 2, 1), day(2025, 2, 28), 27, 24, 25},
+		{"leap day, mar1 policy, non-leap year", Calendar{LeapDay: LeapDayMar1}, leapling, day(2025, 2, 1), day(2025, 3, 1), 28, 24, 25},
+		{"leap day, mar1 policy, seen on Feb 28", Calendar{LeapDay: LeapDayMar1}, leapling, day(2025, 2, 28), day(2025, 3, 1), 1, 24, 25},
+		{"leap day, feb28 policy, just missed", Calendar{LeapDay: LeapDayFeb28}, leapling, day(2025, 3, 1), day(2026, 2, 28), 364, 25, 26},
+		{"leap day into a leap year", Calendar{LeapDay: LeapDayMar1}, leapling, day(2027, 12, 31), day(2028, 2, 29), 60, 27, 28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.calendar.NextOccurrence(tt.birthday, tt.from)
+			if want := startOfDay(tt.wantDate); !got.Equal(want) {
+				t.Errorf("NextOccurrence = %s, want %s", got.Format("2006-01-02"), want.Format("2006-01-02"))
+			}
+			if got := tt.calendar.DaysUntil(tt.birthday, tt.from); got != tt.wantDays {
+				t.Errorf("DaysUntil = %d, want %d", got, tt.wantDays)
+			}
+			if got, _ := tt.calendar.AgeOn(tt.birthday, tt.from); got != tt.wantAge {
+				t.Errorf("AgeOn = %d, want %d", got, tt.wantAge)
+			}
+			if got, _ := tt.calendar.TurningOn(tt.birthday, tt.from); got != tt.turningOn {
+				t.Errorf("TurningOn = %d, want %d", got, tt.turningOn)
+			}
+		})
+	}
+}
+
+func TestDaysUntilAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	b := Birthday{Name: "Spring", Month: 3, Day: 10}
+	from := time.Date(2025, 3, 8, 23, 0, 0, 0, loc)
+	if got := (Calendar{}).DaysUntil(b, from); got != 2 {
+		t.Errorf("DaysUntil across the DST change = %d, want 2", got)
+	}
+}
+
+func TestBirthdaysOn(t *testing.T) {
+	repo, err := NewBirthdayStore(filepath.Join(t.TempDir(), "birthdays.json"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []NewBirthday{
+		{Name: "Leap", Date: "2000-02-29"},
+		{Name: "Feb", Date: "02-28"},
+		{Name: "Mar", Date: "03-01"},
+		{Name: "Dec", Date: "12-31"},
+		{Name: "Jan", Date: "01-01"},
+	} {
+		if _, err := repo.AddBirthday(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	feb28 := Calendar{LeapDay: LeapDayFeb28}
+	mar1 := Calendar{LeapDay: LeapDayMar1}
+
+	// The reminder job looks up tomorrow, so Dec 31 must find Jan 1 of the
+	// next year
+	tests := []struct {
+		name     string
+		calendar Calendar
+		on       time.Time
+		want     []string
+	}{
+		{"today on Dec 31", feb28, day(2025, 12, 31), []string{"Dec"}},
+		{"tomorrow from Dec 31", feb28, day(2025, 12, 31).AddDate(0, 0, 1), []string{"Jan"}},
+		{"feb28 policy, non-leap Feb 28", feb28, day(2025, 2, 28), []string{"Feb", "Leap"}},
+		{"feb28 policy, non-leap Mar 1", feb28, day(2025, 3, 1), []string{"Mar"}},
+		{"mar1 policy, non-leap Feb 28", mar1, day(2025, 2, 28), []string{"Feb"}},
+		{"mar1 policy, non-leap Mar 1", mar1, day(2025, 3, 1), []string{"Mar", "Leap"}},
+		{"leap year Feb 28", mar1, day(2024, 2, 28), []string{"Feb"}},
+		{"leap year Feb 29", feb28, day(2024, 2, 29), []string{"Leap"}},
+		{"leap year Mar 1", mar1, day(2024, 3, 1), []string{"Mar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := BirthdaysOn(repo, tt.calendar, AllTenants, tt.on)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, b := range matches {
+				got = append(got, b.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("BirthdaysOn(%s) = %v, want %v", tt.on.Format("2006-01-02"), got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("BirthdaysOn(%s) = %v, want %v", tt.on.Format("2006-01-02"), got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrPushConfigNotFound is returned when a task has no push notification
+// config with the given ID
+var ErrPushConfigNotFound = errors.New("push notification config not found")
+
+// PushConfig is where an A2A client asked to be told about a task's
+// updates. Configs also subscribe the task's tenant to the daily reminders,
+// which are delivered as new tasks in the same context.
+type PushConfig struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"task_id"`
+	ContextID string `json:"context_id,omitempty"`
+	Tenant    string `json:"tenant"`
+	URL       string `json:"url"`
+	// Token is sent back in the X-A2A-Notification-Token header so the
+	// client can check the notification is meant for it
+	Token string `json:"token,omitempty"`
+	// AuthSchemes and Credentials authenticate Hazel to the client's server,
+	// e.g. ["Bearer"] and the bearer token
+	AuthSchemes []string  `json:"auth_schemes,omitempty"`
+	Credentials string    `json:"credentials,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PushConfigs keeps the push notification configs registered by A2A
+// clients, persisted so reminders keep going out across restarts
+type PushConfigs struct {
+	mu      sync.Mutex
+	configs []PushConfig
+	file    string
+}
+
+// PushConfigFileFor returns the push config path that sits next to a
+// birthday store file
+func PushConfigFileFor(storeFile string) string {
+	return filepath.Join(filepath.Dir(storeFile), "push.json")
+}
+
+// NewPushConfigs loads the configs kept in filename, starting empty when it
+// doesn't exist yet
+func NewPushConfigs(filename string) (*PushConfigs, error) {
+	p := &PushConfigs{file: filename}
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push notification configs: %w", err)
+	}
+	if err := json.Unmarshal(data, &p.configs); err != nil {
+		return nil, &CorruptFileError{Path: filename, Err: err}
+	}
+	return p, nil
+}
+
+// Set adds the config, or replaces the task's config with the same ID. A
+// config without an ID gets a new one.
+func (p *PushConfigs) Set(config PushConfig) (PushConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if config.ID == "" {
+		config.ID = uuid.New().String()
+	}
+	if config.CreatedAt.IsZero() {
+		config.CreatedAt = time.Now()
+	}
+
+	old := slices.Clone(p.configs)
+	if i := p.index(config.TaskID, config.ID); i >= 0 {
+		p.configs[i] = config
+	} else {
+		p.configs = append(p.configs, config)
+	}
+	if err := p.save(); err != nil {
+		p.configs = old
+		return PushConfig{}, err
+	}
+	return config, nil
+}
+
+// Get returns one of the task's configs. An empty id means the task's only
+// or newest config.
+func (p *PushConfigs) Get(taskID, id string) (PushConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id == "" {
+		for i := len(p.configs) - 1; i >= 0; i-- {
+			if p.configs[i].TaskID == taskID {
+				return p.configs[i], nil
+			}
+		}
+		return PushConfig{}, ErrPushConfigNotFound
+	}
+	i := p.index(taskID, id)
+	if i < 0 {
+		return PushConfig{}, ErrPushConfigNotFound
+	}
+	return p.configs[i], nil
+}
+
+// List returns the task's configs, oldest first
+func (p *PushConfigs) List(taskID string) []PushConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	list := []PushConfig{}
+	for _, c := range p.configs {
+		if c.TaskID == taskID {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Delete removes one of the task's configs
+func (p *PushConfigs) Delete(taskID, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.index(taskID, id)
+	if i < 0 {
+		return ErrPushConfigNotFound
+	}
+	old := slices.Clone(p.configs)
+	p.configs = slices.Delete(p.configs, i, i+1)
+	if err := p.save(); err != nil {
+		p.configs = old
+		return err
+	}
+	return nil
+}
+
+// ForTenant returns the configs subscribing the tenant to reminders. A URL
+// registered more than once with the same token is only returned once, with
+// its newest config.
+func (p *PushConfigs) ForTenant(tenant string) []PushConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	newest := make(map[string]PushConfig)
+	for _, c := range p.configs {
+		if c.Tenant != tenant {
+			continue
+		}
+		key := c.URL + "|" + c.Token
+		if old, ok := newest[key]; !ok || !c.CreatedAt.Before(old.CreatedAt) {
+			newest[key] = c
+		}
+	}
+
+	list := make([]PushConfig, 0, len(newest))
+	for _, c := range newest {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// index finds the task's config with the given ID, or -1. The caller must
+// hold p.mu.
+func (p *PushConfigs) index(taskID, id string) int {
+	for i, c := range p.configs {
+		if c.TaskID == taskID && c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// save writes the configs to disk. The caller must hold p.mu.
+func (p *PushConfigs) save() error {
+	data, err := json.MarshalIndent(p.configs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode push notification configs: %w", err)
+	}
+	if err := writeFileAtomic(p.file, data, 0); err != nil {
+		return fmt.Errorf("failed to write push notification configs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPushConfigsPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "push.json")
+	p, err := NewPushConfigs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := p.Set(PushConfig{TaskID: "task", Tenant: "tenant", URL: "http://example.test/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID == "" || saved.CreatedAt.IsZero() {
+		t.Errorf("Set returned %+v, want an ID and creation time filled in", saved)
+	}
+
+	reloaded, err := NewPushConfigs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reloaded.Get("task", saved.ID); err != nil || got.URL != saved.URL {
+		t.Errorf("after reload Get = %+v, %v; want the saved config", got, err)
+	}
+
+	if err := reloaded.Delete("task", saved.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Delete("task", saved.ID); err != ErrPushConfigNotFound {
+		t.Errorf("second Delete: err = %v, want ErrPushConfigNotFound", err)
+	}
+}
+
+func TestPushConfigsRollBackFailedSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPushConfigs(filepath.Join(dir, "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := p.Set(PushConfig{TaskID: "task", Tenant: "tenant", URL: "http://example.test/one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving fails once the directory is gone
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Set(PushConfig{TaskID: "task", Tenant: "tenant", URL: "http://example.test/two"}); err == nil {
+		t.Fatal("Set succeeded without a directory to save in")
+	}
+	if list := p.List("task"); len(list) != 1 || list[0].ID != first.ID {
+		t.Errorf("after a failed add, configs = %+v, want only the first", list)
+	}
+
+	replaced := first
+	replaced.URL = "http://example.test/replaced"
+	if _, err := p.Set(replaced); err == nil {
+		t.Fatal("Set succeeded without a directory to save in")
+	}
+	if got, _ := p.Get("task", first.ID); got.URL != first.URL {
+		t.Errorf("after a failed replace, URL = %s, want %s", got.URL, first.URL)
+	}
+
+	if err := p.Delete("task", first.ID); err == nil {
+		t.Fatal("Delete succeeded without a directory to save in")
+	}
+	if list := p.List("task"); len(list) != 1 || list[0].URL != first.URL {
+		t.Errorf("after a failed delete, configs = %+v, want the first still there", list)
+	}
+}
+
+func TestPushConfigsForTenant(t *testing.T) {
+	p, err := NewPushConfigs(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []PushConfig{
+		{TaskID: "a", Tenant: "tenant", URL: "http://example.test/hook", Token: "t"},
+		{TaskID: "b", Tenant: "tenant", URL: "http://example.test/hook", Token: "t"},
+		{TaskID: "c", Tenant: "tenant", URL: "http://example.test/other"},
+		{TaskID: "d", Tenant: "someone-else", URL: "http://example.test/hook", Token: "t"},
+	} {
+		if _, err := p.Set(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configs := p.ForTenant("tenant")
+	if len(configs) != 2 {
+		t.Fatalf("ForTenant returned %d configs, want 2: %+v", len(configs), configs)
+	}
+	for _, c := range configs {
+		if c.TaskID == "a" {
+			t.Errorf("ForTenant kept the older duplicate of %s", c.URL)
+		}
+	}
+}
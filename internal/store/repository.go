@@ -0,0 +1,63 @@
+package store
+
+import "fmt"
+
+// Storage backends selectable from config
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Options tunes the file-based backends
+type Options struct {
+	// Backups is how many previous versions of the JSON file to keep
+	Backups int
+	// RecoverFromBackup restores the newest good backup when the file is corrupt
+	RecoverFromBackup bool
+}
+
+// AllTenants can be passed as the tenant to reach every birthday book at
+// once. Only background jobs should use it; requests are always scoped.
+const AllTenants = "*"
+
+// DefaultTenant is the book used when a request carries no tenant
+const DefaultTenant = ""
+
+// Repository is the storage interface for birthdays. Every method returns
+// the backend's errors so callers can report them instead of losing data.
+// Reads and writes are scoped to a tenant; a birthday in another tenant's
+// book is reported as ErrNotFound.
+type Repository interface {
+	AddBirthday(input NewBirthday) (string, error)
+	List(tenant string) ([]Birthday, error)
+	ListByDate(tenant string, month, day int) ([]Birthday, error)
+	Get(tenant, id string) (Birthday, error)
+	Update(tenant, id string, input NewBirthday) (Birthday, error)
+	Patch(tenant, id string, patch BirthdayPatch) (Birthday, error)
+	Delete(tenant, id string) error
+	// Restore puts back a birthday previously read from the store, to undo
+	// a change or deletion
+	Restore(b Birthday) error
+	Close() error
+}
+
+func inTenant(b Birthday, tenant string) bool {
+	return tenant == AllTenants || b.Tenant == tenant
+}
+
+var (
+	_ Repository = (*BirthdayStore)(nil)
+	_ Repository = (*SQLiteStore)(nil)
+)
+
+// Open creates the repository for the named backend, stored at path
+func Open(backend, path string, opts Options) (Repository, error) {
+	switch backend {
+	case BackendJSON, "":
+		return NewBirthdayStore(path, opts)
+	case BackendSQLite:
+		return NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q (expected %q or %q)", backend, BackendJSON, BackendSQLite)
+	}
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order and recorded in schema_migrations.
+// Never edit an existing entry; append a new one instead.
+var migrations = []string{
+	// 1: initial schema
+	`CREATE TABLE birthdays (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		month      INTEGER NOT NULL,
+		day        INTEGER NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX idx_birthdays_month_day ON birthdays (month, day);`,
+
+	// 2: optional birth year and the flag that hides it
+	`ALTER TABLE birthdays ADD COLUMN year INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE birthdays ADD COLUMN hide_year INTEGER NOT NULL DEFAULT 0;`,
+
+	// 3: per-tenant birthday books
+	`ALTER TABLE birthdays ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_birthdays_tenant_month_day ON birthdays (tenant, month, day);`,
+
+	// 4: details for personalising wishes; interests is a JSON array
+	`ALTER TABLE birthdays ADD COLUMN interests TEXT NOT NULL DEFAULT '';
+	ALTER TABLE birthdays ADD COLUMN notes TEXT NOT NULL DEFAULT '';`,
+}
+
+const birthdayColumns = `id, name, month, day, year, hide_year, interests, notes, tenant, created_at`
+
+// tenantFilter is appended to WHERE clauses; the tenant is bound twice so
+// AllTenants matches every row
+const tenantFilter = `(? = '` + AllTenants + `' OR tenant = ?)`
+
+// SQLiteStore keeps birthdays in an embedded SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at path and migrates it
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+
+	s := &SQLiteStore{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) AddBirthday(input NewBirthday) (string, error) {
+	b, err := newBirthday(input)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.Exec(`INSERT INTO birthdays (`+birthdayColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		b.ID, b.Name, b.Month, b.Day, b.Year, b.HideYear, encodeInterests(b.Interests), b.Notes, b.Tenant, b.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return "", fmt.Errorf("failed to insert birthday: %w", err)
+	}
+	return b.ID, nil
+}
+
+func (s *SQLiteStore) List(tenant string) ([]Birthday, error) {
+	return s.query(`SELECT `+birthdayColumns+` FROM birthdays WHERE `+tenantFilter+` ORDER BY month, day, name`,
+		tenant, tenant)
+}
+
+// ListByDate returns the birthdays falling on the given month and day
+func (s *SQLiteStore) ListByDate(tenant string, month, day int) ([]Birthday, error) {
+	return s.query(`SELECT `+birthdayColumns+` FROM birthdays WHERE `+tenantFilter+` AND month = ? AND day = ? ORDER BY name`,
+		tenant, tenant, month, day)
+}
+
+// Get returns the birthday with the given ID
+func (s *SQLiteStore) Get(tenant, id string) (Birthday, error) {
+	birthdays, err := s.query(`SELECT `+birthdayColumns+` FROM birthdays WHERE `+tenantFilter+` AND id = ?`,
+		tenant, tenant, id)
+	if err != nil {
+		return Birthday{}, err
+	}
+	if len(birthdays) == 0 {
+		return Birthday{}, ErrNotFound
+	}
+	return birthdays[0], nil
+}
+
+// Update replaces all the editable fields of an existing birthday
+func (s *SQLiteStore) Update(tenant, id string, input NewBirthday) (Birthday, error) {
+	return s.Patch(tenant, id, input.patch())
+}
+
+// Patch changes only the fields set in the patch. The read and the update
+// run in one transaction so a concurrent change isn't overwritten.
+func (s *SQLiteStore) Patch(tenant, id string, patch BirthdayPatch) (Birthday, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Birthday{}, fmt.Errorf("failed to start update: %w", err)
+	}
+	defer tx.Rollback()
+
+	birthdays, err := queryBirthdays(tx, `SELECT `+birthdayColumns+` FROM birthdays WHERE `+tenantFilter+` AND id = ?`,
+		tenant, tenant, id)
+	if err != nil {
+		return Birthday{}, err
+	}
+	if len(birthdays) == 0 {
+		return Birthday{}, ErrNotFound
+	}
+	b := birthdays[0]
+	if err := applyPatch(&b, patch); err != nil {
+		return Birthday{}, err
+	}
+
+	res, err := tx.Exec(`UPDATE birthdays SET name = ?, month = ?, day = ?, year = ?, hide_year = ?, interests = ?, notes = ? WHERE id = ? AND tenant = ?`,
+		b.Name, b.Month, b.Day, b.Year, b.HideYear, encodeInterests(b.Interests), b.Notes, b.ID, b.Tenant)
+	if err != nil {
+		return Birthday{}, fmt.Errorf("failed to update birthday: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Birthday{}, ErrNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return Birthday{}, fmt.Errorf("failed to update birthday: %w", err)
+	}
+	return b, nil
+}
+
+// Delete removes the birthday with the given ID
+func (s *SQLiteStore) Delete(tenant, id string) error {
+	res, err := s.db.Exec(`DELETE FROM birthdays WHERE `+tenantFilter+` AND id = ?`, tenant, tenant, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete birthday: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete birthday: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Restore saves b exactly as given, keeping its ID, tenant and creation
+// time, whether or not it still exists
+func (s *SQLiteStore) Restore(b Birthday) error {
+	_, err := s.db.Exec(`INSERT INTO birthdays (`+birthdayColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, month = excluded.month, day = excluded.day,
+			year = excluded.year, hide_year = excluded.hide_year, interests = excluded.interests, notes = excluded.notes,
+			tenant = excluded.tenant, created_at = excluded.created_at`,
+		b.ID, b.Name, b.Month, b.Day, b.Year, b.HideYear, encodeInterests(b.Interests), b.Notes, b.Tenant, b.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("failed to restore birthday: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) query(query string, args ...any) ([]Birthday, error) {
+	return queryBirthdays(s.db, query, args...)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func queryBirthdays(q querier, query string, args ...any) ([]Birthday, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query birthdays: %w", err)
+	}
+	defer rows.Close()
+
+	birthdays := []Birthday{}
+	for rows.Next() {
+		var b Birthday
+		var interests, createdAt string
+		if err := rows.Scan(&b.ID, &b.Name, &b.Month, &b.Day, &b.Year, &b.HideYear, &interests, &b.Notes, &b.Tenant, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan birthday: %w", err)
+		}
+		if interests != "" {
+			if err := json.Unmarshal([]byte(interests), &b.Interests); err != nil {
+				return nil, fmt.Errorf("invalid interests for birthday %s: %w", b.ID, err)
+			}
+		}
+		if b.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, fmt.Errorf("invalid created_at for birthday %s: %w", b.ID, err)
+		}
+		birthdays = append(birthdays, b)
+	}
+	if err := rows.Err(); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read birthdays: %w", err)
+	}
+	return birthdays, nil
+}
+
+// encodeInterests stores interests as a JSON array, or "" when there are none
+func encodeInterests(interests []string) string {
+	if len(interests) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(interests)
+	return string(data)
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "birthdays.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// openAtVersion creates a database with only the first n migrations
+// applied, as an older release would have left it
+func openAtVersion(t *testing.T, path string, n int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := db.Exec(migrations[i]); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, "2024-01-01T00:00:00Z"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestSQLiteMigratesExistingDatabase(t *testing.T) {
+	created := "2024-01-01T00:00:00Z"
+	tests := []struct {
+		version int
+		insert  string
+		args    []any
+		want    Birthday
+	}{
+		{
+			version: 1,
+			insert:  `INSERT INTO birthdays (id, name, month, day, created_at) VALUES (?, ?, ?, ?, ?)`,
+			args:    []any{"b1", "Ada", 12, 10, created},
+			want:    Birthday{ID: "b1", Name: "Ada", Month: 12, Day: 10},
+		},
+		{
+			version: 2,
+			insert:  `INSERT INTO birthdays (id, name, month, day, year, hide_year, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			args:    []any{"b1", "Ada", 12, 10, 1815, 1, created},
+			want:    Birthday{ID: "b1", Name: "Ada", Month: 12, Day: 10, Year: 1815, HideYear: true},
+		},
+		{
+			version: 3,
+			insert:  `INSERT INTO birthdays (id, name, month, day, year, hide_year, tenant, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			args:    []any{"b1", "Ada", 12, 10, 1815, 0, "team-a", created},
+			want:    Birthday{ID: "b1", Name: "Ada", Month: 12, Day: 10, Year: 1815, Tenant: "team-a"},
+		},
+		{
+			version: 4,
+			insert:  `INSERT INTO birthdays (id, name, month, day, year, hide_year, tenant, interests, notes, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			args:    []any{"b1", "Ada", 12, 10, 0, 0, "team-a", `["maths"]`, "likes engines", created},
+			want:    Birthday{ID: "b1", Name: "Ada", Month: 12, Day: 10, Tenant: "team-a", Interests: []string{"maths"}, Notes: "likes engines"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("from version %d", tt.version), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "birthdays.db")
+			db := openAtVersion(t, path, tt.version)
+			if _, err := db.Exec(tt.insert, tt.args...); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			s, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore on a version %d database: %v", tt.version, err)
+			}
+			defer s.Close()
+
+			var version int
+			if err := s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+				t.Fatal(err)
+			}
+			if version != len(migrations) {
+				t.Errorf("schema version = %d, want %d", version, len(migrations))
+			}
+
+			got, err := s.Get(AllTenants, "b1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.CreatedAt, _ = time.Parse(time.RFC3339, created)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("after migrating got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteTenantIsolation(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	a, err := s.AddBirthday(NewBirthday{Name: "Ada", Date: "12-10", Tenant: "team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddBirthday(NewBirthday{Name: "Grace", Date: "12-09", Tenant: "team-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := s.List("team-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "Grace" {
+		t.Errorf("team-b list = %+v, want only Grace", list)
+	}
+	if all, err := s.List(AllTenants); err != nil || len(all) != 2 {
+		t.Errorf("List(AllTenants) = %d birthdays, %v; want 2", len(all), err)
+	}
+	if byDate, err := s.ListByDate("team-b", 12, 10); err != nil || len(byDate) != 0 {
+		t.Errorf("team-b ListByDate(12, 10) = %+v, %v; want none", byDate, err)
+	}
+
+	if _, err := s.Get("team-b", a); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get from another tenant: err = %v, want ErrNotFound", err)
+	}
+	name := "Mallory"
+	if _, err := s.Patch("team-b", a, BirthdayPatch{Name: &name}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Patch from another tenant: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("team-b", a); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete from another tenant: err = %v, want ErrNotFound", err)
+	}
+	if got, err := s.Get("team-a", a); err != nil || got.Name != "Ada" {
+		t.Errorf("Get(team-a) = %+v, %v; want Ada untouched", got, err)
+	}
+}
+
+func TestSQLitePatch(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	id, err := s.AddBirthday(NewBirthday{Name: "Ada", Date: "1815-12-10", Tenant: "team-a", Interests: []string{"maths"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes := "likes engines"
+	got, err := s.Patch("team-a", id, BirthdayPatch{Notes: &notes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Ada" || got.Year != 1815 || got.Notes != notes || !reflect.DeepEqual(got.Interests, []string{"maths"}) {
+		t.Errorf("Patch returned %+v, want only the notes changed", got)
+	}
+	if saved, err := s.Get("team-a", id); err != nil || !reflect.DeepEqual(saved, got) {
+		t.Errorf("Get after Patch = %+v, %v; want %+v", saved, err, got)
+	}
+
+	// Reaching it through AllTenants keeps it in its own book
+	date := "12-11"
+	if got, err := s.Patch(AllTenants, id, BirthdayPatch{Date: &date}); err != nil || got.Tenant != "team-a" || got.Day != 11 || got.Year != 0 {
+		t.Errorf("Patch(AllTenants) = %+v, %v; want team-a on Dec 11 with no year", got, err)
+	}
+
+	empty := " "
+	if _, err := s.Patch("team-a", id, BirthdayPatch{Name: &empty}); !errors.Is(err, ErrEmptyName) {
+		t.Errorf("Patch with an empty name: err = %v, want ErrEmptyName", err)
+	}
+	if _, err := s.Patch("team-a", "missing", BirthdayPatch{Notes: &notes}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Patch of a missing birthday: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteRestore(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	id, err := s.AddBirthday(NewBirthday{Name: "Ada", Date: "12-10", Tenant: "team-a", Notes: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := s.Get("team-a", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restoring a deleted birthday brings back its ID and creation time
+	if err := s.Delete("team-a", id); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(original); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get("team-a", id); err != nil || !reflect.DeepEqual(got, original) {
+		t.Errorf("after restoring a deleted birthday Get = %+v, %v; want %+v", got, err, original)
+	}
+
+	// Restoring over an edited one undoes the edit
+	name := "Grace"
+	if _, err := s.Patch("team-a", id, BirthdayPatch{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(original); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get("team-a", id); err != nil || !reflect.DeepEqual(got, original) {
+		t.Errorf("after restoring an edited birthday Get = %+v, %v; want %+v", got, err, original)
+	}
+	if list, err := s.List(AllTenants); err != nil || len(list) != 1 {
+		t.Errorf("List = %d birthdays, %v; want 1", len(list), err)
+	}
+}
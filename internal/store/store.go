@@ -2,7 +2,11 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,83 +14,373 @@ import (
 )
 
 type Birthday struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Month     int       `json:"month"`
-	Day       int       `json:"day"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Month int    `json:"month"`
+	Day   int    `json:"day"`
+	// Year is the birth year, or 0 when it isn't known
+	Year int `json:"year,omitempty"`
+	// HideYear keeps the birth year (and so the age) out of every response
+	HideYear bool `json:"hide_year,omitempty"`
+	// Interests and Notes help personalise generated wishes
+	Interests []string `json:"interests,omitempty"`
+	Notes     string   `json:"notes,omitempty"`
+	// Tenant is the birthday book this entry belongs to (see AllTenants)
+	Tenant    string    `json:"tenant,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// NewBirthday is the input for adding or replacing a birthday
+type NewBirthday struct {
+	Name string
+	// Date is "YYYY-MM-DD", or "MM-DD" when the year isn't known
+	Date      string
+	HideYear  bool
+	Interests []string
+	Notes     string
+	// Tenant is only used when adding; a birthday never moves between books
+	Tenant string
+}
+
+var (
+	ErrNotFound    = errors.New("birthday not found")
+	ErrEmptyName   = errors.New("name is required")
+	ErrInvalidDate = errors.New("invalid date")
+	ErrTooLong     = errors.New("too long")
+)
+
+// Limits on the details kept for personalising wishes
+const (
+	MaxInterests      = 10
+	MaxInterestLength = 50
+	MaxNotesLength    = 500
+)
+
+// BirthdayPatch holds the fields to change on a birthday. Nil fields are left as-is.
+type BirthdayPatch struct {
+	Name      *string   `json:"name"`
+	Date      *string   `json:"date"`
+	HideYear  *bool     `json:"hide_year"`
+	Interests *[]string `json:"interests"`
+	Notes     *string   `json:"notes"`
+}
+
+// BirthdayStore keeps birthdays in memory and persists them to a JSON file
 type BirthdayStore struct {
 	mu        sync.RWMutex
 	birthdays map[string]Birthday
 	file      string
+	opts      Options
 }
 
-func NewBirthdayStore(filename string) *BirthdayStore {
+// NewBirthdayStore loads the store from filename. A missing file starts an
+// empty store. A malformed one is a *CorruptFileError unless
+// opts.RecoverFromBackup is set and a good backup exists.
+func NewBirthdayStore(filename string, opts Options) (*BirthdayStore, error) {
 	store := &BirthdayStore{
 		birthdays: make(map[string]Birthday),
 		file:      filename,
+		opts:      opts,
 	}
-	store.load()
-	return store
-}
-
-func (bs *BirthdayStore) AddBirthday(name, date string) (string, error) {
-	var t time.Time
-	var err error
-
-	if len(date) == 5 {
-		t, err = time.Parse("01-02", date)
-		if err != nil {
-			return "", err
-		}
-	} else {
-		t, err = time.Parse("2006-01-02", date)
-		if err != nil {
-			return "", err
-		}
+	if err := store.load(); err != nil {
+		return nil, err
 	}
+	return store, nil
+}
 
-	birthday := Birthday{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Month:     int(t.Month()),
-		Day:       t.Day(),
-		CreatedAt: time.Now(),
+func (bs *BirthdayStore) AddBirthday(input NewBirthday) (string, error) {
+	birthday, err := newBirthday(input)
+	if err != nil {
+		return "", err
 	}
 
 	bs.mu.Lock()
-	bs.birthdays[birthday.ID] = birthday
-	bs.mu.Unlock()
+	defer bs.mu.Unlock()
 
-	bs.save()
+	bs.birthdays[birthday.ID] = birthday
+	if err := bs.save(); err != nil {
+		delete(bs.birthdays, birthday.ID)
+		return "", err
+	}
 	return birthday.ID, nil
 }
 
-func (bs *BirthdayStore) List() []Birthday {
+func (bs *BirthdayStore) List(tenant string) ([]Birthday, error) {
 	bs.mu.RLock()
 	defer bs.mu.RUnlock()
 
 	birthdays := make([]Birthday, 0, len(bs.birthdays))
 	for _, b := range bs.birthdays {
-		birthdays = append(birthdays, b)
+		if inTenant(b, tenant) {
+			birthdays = append(birthdays, b)
+		}
 	}
-	return birthdays
+	return birthdays, nil
 }
 
-func (bs *BirthdayStore) save() {
+// ListByDate returns the birthdays falling on the given month and day
+func (bs *BirthdayStore) ListByDate(tenant string, month, day int) ([]Birthday, error) {
 	bs.mu.RLock()
 	defer bs.mu.RUnlock()
 
-	data, _ := json.MarshalIndent(bs.birthdays, "", "  ")
-	os.WriteFile(bs.file, data, 0644)
+	var birthdays []Birthday
+	for _, b := range bs.birthdays {
+		if b.Month == month && b.Day == day && inTenant(b, tenant) {
+			birthdays = append(birthdays, b)
+		}
+	}
+	return birthdays, nil
 }
 
-func (bs *BirthdayStore) load() {
+// Get returns the birthday with the given ID
+func (bs *BirthdayStore) Get(tenant, id string) (Birthday, error) {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+
+	b, ok := bs.birthdays[id]
+	if !ok || !inTenant(b, tenant) {
+		return Birthday{}, ErrNotFound
+	}
+	return b, nil
+}
+
+// Update replaces all the editable fields of an existing birthday
+func (bs *BirthdayStore) Update(tenant, id string, input NewBirthday) (Birthday, error) {
+	return bs.Patch(tenant, id, input.patch())
+}
+
+// Patch changes only the fields set in the patch
+func (bs *BirthdayStore) Patch(tenant, id string, patch BirthdayPatch) (Birthday, error) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	old, ok := bs.birthdays[id]
+	if !ok || !inTenant(old, tenant) {
+		return Birthday{}, ErrNotFound
+	}
+
+	b := old
+	if err := applyPatch(&b, patch); err != nil {
+		return Birthday{}, err
+	}
+
+	bs.birthdays[id] = b
+	if err := bs.save(); err != nil {
+		bs.birthdays[id] = old
+		return Birthday{}, err
+	}
+	return b, nil
+}
+
+// Delete removes the birthday with the given ID
+func (bs *BirthdayStore) Delete(tenant, id string) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	old, ok := bs.birthdays[id]
+	if !ok || !inTenant(old, tenant) {
+		return ErrNotFound
+	}
+
+	delete(bs.birthdays, id)
+	if err := bs.save(); err != nil {
+		bs.birthdays[id] = old
+		return err
+	}
+	return nil
+}
+
+// Restore saves b exactly as given, keeping its ID, tenant and creation
+// time, whether or not it still exists
+func (bs *BirthdayStore) Restore(b Birthday) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	old, existed := bs.birthdays[b.ID]
+	bs.birthdays[b.ID] = b
+	if err := bs.save(); err != nil {
+		if existed {
+			bs.birthdays[b.ID] = old
+		} else {
+			delete(bs.birthdays, b.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Close is a no-op for the JSON store; every change is already on disk
+func (bs *BirthdayStore) Close() error {
+	return nil
+}
+
+// newBirthday validates the input and builds a new record with a fresh ID
+func newBirthday(input NewBirthday) (Birthday, error) {
+	b := Birthday{
+		ID:        uuid.New().String(),
+		Tenant:    input.Tenant,
+		CreatedAt: time.Now(),
+	}
+	if err := applyPatch(&b, input.patch()); err != nil {
+		return Birthday{}, err
+	}
+	return b, nil
+}
+
+func (input NewBirthday) patch() BirthdayPatch {
+	return BirthdayPatch{Name: &input.Name, Date: &input.Date, HideYear: &input.HideYear, Interests: &input.Interests, Notes: &input.Notes}
+}
+
+// applyPatch validates the patch and applies it to b
+func applyPatch(b *Birthday, patch BirthdayPatch) error {
+	if patch.Name != nil {
+		name := strings.TrimSpace(*patch.Name)
+		if name == "" {
+			return ErrEmptyName
+		}
+		b.Name = name
+	}
+
+	if patch.Date != nil {
+		t, hasYear, err := parseDate(*patch.Date)
+		if err != nil {
+			return err
+		}
+		b.Month = int(t.Month())
+		b.Day = t.Day()
+		b.Year = 0
+		if hasYear {
+			b.Year = t.Year()
+		}
+	}
+
+	if patch.HideYear != nil {
+		b.HideYear = *patch.HideYear
+	}
+
+	if patch.Interests != nil {
+		var interests []string
+		for _, interest := range *patch.Interests {
+			interest = strings.TrimSpace(interest)
+			if interest == "" {
+				continue
+			}
+			if len(interest) > MaxInterestLength {
+				return fmt.Errorf("%w: interests are limited to %d characters each", ErrTooLong, MaxInterestLength)
+			}
+			interests = append(interests, interest)
+		}
+		if len(interests) > MaxInterests {
+			return fmt.Errorf("%w: at most %d interests can be saved", ErrTooLong, MaxInterests)
+		}
+		b.Interests = interests
+	}
+
+	if patch.Notes != nil {
+		notes := strings.TrimSpace(*patch.Notes)
+		if len(notes) > MaxNotesLength {
+			return fmt.Errorf("%w: notes are limited to %d characters", ErrTooLong, MaxNotesLength)
+		}
+		b.Notes = notes
+	}
+	return nil
+}
+
+// parseDate accepts "2006-01-02" or the year-less "01-02" and reports
+// whether the year was given
+func parseDate(date string) (time.Time, bool, error) {
+	date = strings.TrimSpace(date)
+	layout, hasYear := "2006-01-02", true
+	if len(date) == 5 {
+		layout, hasYear = "01-02", false
+	}
+
+	t, err := time.Parse(layout, date)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w %q: use YYYY-MM-DD or MM-DD", ErrInvalidDate, date)
+	}
+	if hasYear && t.After(time.Now()) {
+		return time.Time{}, false, fmt.Errorf("%w %q: birth date is in the future", ErrInvalidDate, date)
+	}
+	return t, hasYear, nil
+}
+
+// save writes the store to disk. The caller must hold bs.mu.
+func (bs *BirthdayStore) save() error {
+	data, err := json.MarshalIndent(bs.birthdays, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode birthdays: %w", err)
+	}
+	return writeFileAtomic(bs.file, data, bs.opts.Backups)
+}
+
+func (bs *BirthdayStore) load() error {
 	data, err := os.ReadFile(bs.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return
+		return fmt.Errorf("failed to read %s: %w", bs.file, err)
+	}
+
+	birthdays, err := decodeBirthdays(data)
+	if err == nil {
+		bs.birthdays = birthdays
+		return nil
+	}
+
+	corrupt := &CorruptFileError{Path: bs.file, Err: err}
+	if !bs.opts.RecoverFromBackup {
+		return corrupt
+	}
+	return bs.recover(corrupt)
+}
+
+// recover restores the newest backup that still parses. The corrupt file is
+// kept alongside for inspection rather than deleted.
+func (bs *BirthdayStore) recover(corrupt *CorruptFileError) error {
+	for n := 1; n <= bs.opts.Backups; n++ {
+		path := backupPath(bs.file, n)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		birthdays, err := decodeBirthdays(data)
+		if err != nil {
+			log.Printf("Backup %s is also unreadable: %v", path, err)
+			continue
+		}
+
+		quarantine := fmt.Sprintf("%s.corrupt-%s", bs.file, time.Now().Format("20060102-150405"))
+		if err := os.Rename(bs.file, quarantine); err != nil {
+			return fmt.Errorf("failed to move corrupt %s aside: %w", bs.file, err)
+		}
+		if err := writeFileAtomic(bs.file, data, 0); err != nil {
+			return fmt.Errorf("failed to restore %s from %s: %w", bs.file, path, err)
+		}
+
+		log.Printf("⚠️ %s was corrupt (%v); restored %d birthdays from %s, corrupt copy kept at %s",
+			bs.file, corrupt.Err, len(birthdays), path, quarantine)
+		bs.birthdays = birthdays
+		return nil
+	}
+
+	return fmt.Errorf("no usable backup found: %w", corrupt)
+}
+
+func decodeBirthdays(data []byte) (map[string]Birthday, error) {
+	birthdays := make(map[string]Birthday)
+
+	// An empty file (e.g. created by the Dockerfile) is an empty store
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return birthdays, nil
+	}
+
+	if err := json.Unmarshal(data, &birthdays); err != nil {
+		return nil, err
+	}
+	if birthdays == nil {
+		birthdays = make(map[string]Birthday)
 	}
-	json.Unmarshal(data, &bs.birthdays)
+	return birthdays, nil
 }
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxWishRecords caps how many wishes are kept per birthday; older ones are dropped
+	maxWishRecords = 50
+	// recentWishes is how many of a person's latest wishes a new one must differ from
+	recentWishes = 10
+)
+
+// WishRecord is a wish Hazel generated for someone
+type WishRecord struct {
+	BirthdayID string `json:"birthday_id"`
+	Text       string `json:"text"`
+	// Source is the provider that wrote it, or "fallback"
+	Source string `json:"source"`
+	Model  string `json:"model,omitempty"`
+	// Template is the template it was rendered from, if any
+	Template  string    `json:"template,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WishHistory remembers the wishes generated for each birthday so the same
+// person doesn't get the same text twice
+type WishHistory struct {
+	mu      sync.Mutex
+	records map[string][]WishRecord
+	file    string
+}
+
+// WishHistoryFileFor returns the wish history path that sits next to a
+// birthday store file
+func WishHistoryFileFor(storeFile string) string {
+	return filepath.Join(filepath.Dir(storeFile), "wishes.json")
+}
+
+// NewWishHistory loads the history kept in filename, starting empty when it
+// doesn't exist yet
+func NewWishHistory(filename string) (*WishHistory, error) {
+	h := &WishHistory{records: make(map[string][]WishRecord), file: filename}
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wish history: %w", err)
+	}
+
+	var records []WishRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, &CorruptFileError{Path: filename, Err: err}
+	}
+	for _, r := range records {
+		h.records[r.BirthdayID] = append(h.records[r.BirthdayID], r)
+	}
+	for id := range h.records {
+		h.sort(id)
+	}
+	return h, nil
+}
+
+// Add records a wish and persists the history
+func (h *WishHistory) Add(r WishRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[r.BirthdayID] = append(h.records[r.BirthdayID], r)
+	h.sort(r.BirthdayID)
+	list := h.records[r.BirthdayID]
+	h.records[r.BirthdayID] = list[max(0, len(list)-maxWishRecords):]
+	return h.save()
+}
+
+// List returns the wishes generated for the birthday, newest first
+func (h *WishHistory) List(birthdayID string) []WishRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := h.records[birthdayID]
+	newest := make([]WishRecord, len(list))
+	for i, r := range list {
+		newest[len(list)-1-i] = r
+	}
+	return newest
+}
+
+// Recent returns the texts and template names of the birthday's latest
+// wishes, for a new wish to avoid
+func (h *WishHistory) Recent(birthdayID string) (texts, templates []string) {
+	list := h.List(birthdayID)
+	for _, r := range list[:min(recentWishes, len(list))] {
+		texts = append(texts, r.Text)
+		if r.Template != "" {
+			templates = append(templates, r.Template)
+		}
+	}
+	return texts, templates
+}
+
+// sort keeps a birthday's records oldest first. The caller must hold h.mu.
+func (h *WishHistory) sort(birthdayID string) {
+	list := h.records[birthdayID]
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+}
+
+// save writes the history to disk. The caller must hold h.mu.
+func (h *WishHistory) save() error {
+	var records []WishRecord
+	for _, list := range h.records {
+		records = append(records, list...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode wish history: %w", err)
+	}
+	if err := writeFileAtomic(h.file, data, 0); err != nil {
+		return fmt.Errorf("failed to write wish history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// addWishes records n wishes for the birthday, a minute apart, numbered from 1
+func addWishes(t *testing.T, h *WishHistory, birthdayID string, n int) {
+	t.Helper()
+	start := time.Date(2025, 6, 15, 8, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		r := WishRecord{BirthdayID: birthdayID, Text: fmt.Sprintf("wish %d", i), Source: "template", CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		if i%2 == 0 {
+			r.Template = fmt.Sprintf("template-%d.tmpl", i)
+		}
+		if err := h.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWishHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wishes.json")
+	h, err := NewWishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addWishes(t, h, "ada", 3)
+	addWishes(t, h, "grace", 1)
+
+	reloaded, err := NewWishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := reloaded.List("ada")
+	if len(list) != 3 || list[0].Text != "wish 3" || list[2].Text != "wish 1" || list[1].Template != "template-2.tmpl" {
+		t.Errorf("after reload List(ada) = %+v, want wishes 3, 2, 1", list)
+	}
+	if list := reloaded.List("grace"); len(list) != 1 {
+		t.Errorf("after reload List(grace) has %d wishes, want 1", len(list))
+	}
+	if list := reloaded.List("nobody"); len(list) != 0 {
+		t.Errorf("List(nobody) = %+v, want none", list)
+	}
+}
+
+func TestWishHistoryTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wishes.json")
+	h, err := NewWishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addWishes(t, h, "ada", maxWishRecords+5)
+	addWishes(t, h, "grace", 2)
+
+	for _, history := range []*WishHistory{h, mustLoadWishHistory(t, path)} {
+		list := history.List("ada")
+		if len(list) != maxWishRecords {
+			t.Fatalf("kept %d wishes, want %d", len(list), maxWishRecords)
+		}
+		if newest, oldest := list[0].Text, list[len(list)-1].Text; newest != fmt.Sprintf("wish %d", maxWishRecords+5) || oldest != "wish 6" {
+			t.Errorf("kept wishes %s to %s, want the latest %d", oldest, newest, maxWishRecords)
+		}
+		if n := len(history.List("grace")); n != 2 {
+			t.Errorf("another birthday kept %d wishes, want 2", n)
+		}
+	}
+}
+
+func TestWishHistoryRecent(t *testing.T) {
+	h, err := NewWishHistory(filepath.Join(t.TempDir(), "wishes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addWishes(t, h, "ada", recentWishes+5)
+
+	texts, templates := h.Recent("ada")
+	if len(texts) != recentWishes || texts[0] != fmt.Sprintf("wish %d", recentWishes+5) || texts[len(texts)-1] != "wish 6" {
+		t.Errorf("Recent texts = %v, want the latest %d", texts, recentWishes)
+	}
+	// Only the even-numbered wishes came from templates
+	if len(templates) != recentWishes/2 || templates[0] != fmt.Sprintf("template-%d.tmpl", recentWishes+4) {
+		t.Errorf("Recent templates = %v, want the latest %d template names", templates, recentWishes/2)
+	}
+
+	if texts, templates := h.Recent("nobody"); len(texts) != 0 || len(templates) != 0 {
+		t.Errorf("Recent(nobody) = %v, %v; want nothing", texts, templates)
+	}
+}
+
+func TestWishHistoryCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wishes.json")
+	if err := os.WriteFile(path, []byte("[{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var corrupt *CorruptFileError
+	if _, err := NewWishHistory(path); !errors.As(err, &corrupt) {
+		t.Errorf("err = %v, want a *CorruptFileError", err)
+	}
+}
+
+func mustLoadWishHistory(t *testing.T, path string) *WishHistory {
+	t.Helper()
+	h, err := NewWishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
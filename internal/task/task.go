@@ -0,0 +1,304 @@
+// Package task keeps the A2A tasks created for chat messages, so clients can
+// follow a request through its states, poll it with tasks/get and stop it
+// with tasks/cancel.
+package task
+
+import (
+	"context"
+	"errors"
+	"hazel_ai/internal/a2a/protocol"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound      = errors.New("task not found")
+	ErrNotCancelable = errors.New("task can no longer be canceled")
+	ErrFinished      = errors.New("task has already finished")
+)
+
+// AllHistory asks Get for the task's whole history
+const AllHistory = -1
+
+// Transition is one state change of a task
+type Transition struct {
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// entry is a task with the bookkeeping kept out of its JSON
+type entry struct {
+	task   protocol.Task
+	tenant string
+	// conversationKey is the chat conversation the task's questions belong to
+	conversationKey string
+	transitions     []Transition
+	// cancel stops the work in progress, if any
+	cancel    context.CancelFunc
+	expiresAt time.Time
+}
+
+// Store keeps tasks in memory. Tasks expire after the TTL from their last
+// change.
+type Store struct {
+	mu    sync.Mutex
+	tasks map[string]*entry
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewStore creates a store whose tasks expire ttl after their last change
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		tasks: make(map[string]*entry),
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// SetClock replaces the store's time source
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Create starts a submitted task in the tenant's book for the user's
+// message. A new context ID is made up when contextID is empty.
+func (s *Store) Create(tenant, contextID, conversationKey string, message protocol.Message) protocol.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	if contextID == "" {
+		contextID = uuid.New().String()
+	}
+	e := &entry{
+		task:            protocol.Task{ID: uuid.New().String(), ContextID: contextID, Kind: "task"},
+		tenant:          tenant,
+		conversationKey: conversationKey,
+	}
+	s.tasks[e.task.ID] = e
+	e.addMessage(message)
+	s.setState(e, protocol.StateSubmitted, nil)
+	return e.snapshot(AllHistory)
+}
+
+// Record stores a completed task that Hazel started on her own, such as a
+// birthday reminder, in the tenant's context
+func (s *Store) Record(tenant, contextID string, reply protocol.Message, artifacts ...protocol.Artifact) protocol.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	if contextID == "" {
+		contextID = uuid.New().String()
+	}
+	e := &entry{
+		task:   protocol.Task{ID: uuid.New().String(), ContextID: contextID, Kind: "task", Artifacts: artifacts},
+		tenant: tenant,
+	}
+	s.tasks[e.task.ID] = e
+	e.addMessage(reply)
+	reply = e.task.History[0]
+	s.setState(e, protocol.StateCompleted, &reply)
+	return e.snapshot(AllHistory)
+}
+
+// Get returns the task with at most historyLength messages of history, or
+// all of it when historyLength is AllHistory. A historyLength of 0 leaves
+// the history out.
+func (s *Store) Get(id string, historyLength int) (protocol.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return protocol.Task{}, err
+	}
+	return e.snapshot(historyLength), nil
+}
+
+// Tenant returns the birthday book the task belongs to
+func (s *Store) Tenant(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return "", err
+	}
+	return e.tenant, nil
+}
+
+// ConversationKey returns the chat conversation the task's questions belong
+// to, empty for tasks Hazel started on her own
+func (s *Store) ConversationKey(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return "", err
+	}
+	return e.conversationKey, nil
+}
+
+// Pending returns the newest task in the tenant's context that is waiting
+// for the user's input
+func (s *Store) Pending(tenant, contextID string) (protocol.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var newest *entry
+	for _, e := range s.tasks {
+		if e.tenant != tenant || e.task.ContextID != contextID || e.task.Status.State != protocol.StateInputRequired || !now.Before(e.expiresAt) {
+			continue
+		}
+		if newest == nil || e.task.Status.Timestamp.After(newest.task.Status.Timestamp) {
+			newest = e
+		}
+	}
+	if newest == nil {
+		return protocol.Task{}, false
+	}
+	return newest.snapshot(AllHistory), true
+}
+
+// Continue adds the user's next message to one of the tenant's tasks
+func (s *Store) Continue(tenant, id string, message protocol.Message) (protocol.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return protocol.Task{}, err
+	}
+	if e.tenant != tenant {
+		return protocol.Task{}, ErrNotFound
+	}
+	if protocol.Terminal(e.task.Status.State) {
+		return protocol.Task{}, ErrFinished
+	}
+	e.addMessage(message)
+	s.touch(e)
+	return e.snapshot(AllHistory), nil
+}
+
+// Start marks the task as working. cancel is called if the task is canceled
+// before it finishes.
+func (s *Store) Start(id string, cancel context.CancelFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if protocol.Terminal(e.task.Status.State) {
+		return ErrFinished
+	}
+	e.cancel = cancel
+	s.setState(e, protocol.StateWorking, nil)
+	return nil
+}
+
+// Finish moves the task to state with the agent's reply, adding any
+// artifacts. A task canceled in the meantime stays canceled.
+func (s *Store) Finish(id, state string, reply protocol.Message, artifacts ...protocol.Artifact) (protocol.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return protocol.Task{}, err
+	}
+	if e.task.Status.State == protocol.StateCanceled {
+		return e.snapshot(AllHistory), nil
+	}
+	e.cancel = nil
+	e.addMessage(reply)
+	e.task.Artifacts = append(e.task.Artifacts, artifacts...)
+	reply = e.task.History[len(e.task.History)-1]
+	s.setState(e, state, &reply)
+	return e.snapshot(AllHistory), nil
+}
+
+// Cancel stops the task and any work in progress for it
+func (s *Store) Cancel(id string) (protocol.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.get(id)
+	if err != nil {
+		return protocol.Task{}, err
+	}
+	if protocol.Terminal(e.task.Status.State) {
+		return protocol.Task{}, ErrNotCancelable
+	}
+	if e.cancel != nil {
+		e.cancel()
+		e.cancel = nil
+	}
+	s.setState(e, protocol.StateCanceled, nil)
+	return e.snapshot(AllHistory), nil
+}
+
+// prune drops expired tasks. The caller must hold s.mu.
+func (s *Store) prune() {
+	now := s.now()
+	for id, e := range s.tasks {
+		if !now.Before(e.expiresAt) {
+			delete(s.tasks, id)
+		}
+	}
+}
+
+// get looks up a live task. The caller must hold s.mu.
+func (s *Store) get(id string) (*entry, error) {
+	e, ok := s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !s.now().Before(e.expiresAt) {
+		delete(s.tasks, id)
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// setState records a state change. The caller must hold s.mu.
+func (s *Store) setState(e *entry, state string, message *protocol.Message) {
+	now := s.now()
+	e.task.Status = protocol.Status{State: state, Message: message, Timestamp: now}
+	e.transitions = append(e.transitions, Transition{State: state, Timestamp: now})
+	s.touch(e)
+}
+
+// touch restarts the task's TTL. The caller must hold s.mu.
+func (s *Store) touch(e *entry) {
+	e.expiresAt = s.now().Add(s.ttl)
+}
+
+// addMessage appends a message to the history, tagged with the task
+func (e *entry) addMessage(message protocol.Message) {
+	message.TaskID = e.task.ID
+	message.ContextID = e.task.ContextID
+	e.task.History = append(e.task.History, message)
+}
+
+// snapshot copies the task so callers can't change the stored one
+func (e *entry) snapshot(historyLength int) protocol.Task {
+	t := e.task
+	history := e.task.History
+	if historyLength >= 0 && len(history) > historyLength {
+		history = history[len(history)-historyLength:]
+	}
+	t.History = append([]protocol.Message(nil), history...)
+	t.Artifacts = append([]protocol.Artifact(nil), e.task.Artifacts...)
+	t.Metadata = map[string]any{"stateTransitions": append([]Transition(nil), e.transitions...)}
+	return t
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"hazel_ai/internal/a2a/protocol"
+	"testing"
+	"time"
+)
+
+// newFinishedTask creates a task with a three-message history
+func newFinishedTask(t *testing.T, s *Store) string {
+	t.Helper()
+	created := s.Create("tenant", "ctx", "", protocol.NewMessage(protocol.RoleUser, "Remember March 3rd"))
+	if err := s.Start(created.ID, func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Finish(created.ID, protocol.StateInputRequired, protocol.NewMessage(protocol.RoleAgent, "Whose birthday is it?")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Continue("tenant", created.ID, protocol.NewMessage(protocol.RoleUser, "Alice")); err != nil {
+		t.Fatal(err)
+	}
+	return created.ID
+}
+
+func TestGetHistoryLength(t *testing.T) {
+	s := NewStore(time.Hour)
+	id := newFinishedTask(t, s)
+
+	tests := []struct {
+		name          string
+		historyLength int
+		want          []string
+	}{
+		{"all", AllHistory, []string{"Remember March 3rd", "Whose birthday is it?", "Alice"}},
+		{"none", 0, nil},
+		{"last one", 1, []string{"Alice"}},
+		{"last two", 2, []string{"Whose birthday is it?", "Alice"}},
+		{"more than there is", 10, []string{"Remember March 3rd", "Whose birthday is it?", "Alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := s.Get(id, tt.historyLength)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(task.History) != len(tt.want) {
+				t.Fatalf("got %d messages of history, want %d", len(task.History), len(tt.want))
+			}
+			for i, message := range task.History {
+				if message.Text() != tt.want[i] {
+					t.Errorf("history[%d] = %q, want %q", i, message.Text(), tt.want[i])
+				}
+				if message.TaskID != id || message.ContextID != "ctx" {
+					t.Errorf("history[%d] is tagged %s/%s, want %s/ctx", i, message.TaskID, message.ContextID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDoesNotShareHistory(t *testing.T) {
+	s := NewStore(time.Hour)
+	id := newFinishedTask(t, s)
+
+	task, err := s.Get(id, AllHistory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.History[0].Parts = protocol.TextParts("changed")
+
+	again, _ := s.Get(id, AllHistory)
+	if again.History[0].Text() != "Remember March 3rd" {
+		t.Errorf("changing a returned task changed the stored one")
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(time.Hour)
+	s.SetClock(func() time.Time { return now })
+	id := newFinishedTask(t, s)
+
+	now = now.Add(2 * time.Hour)
+	if _, err := s.Get(id, AllHistory); err != ErrNotFound {
+		t.Errorf("Get of an expired task: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,402 @@
+// Package templates holds the library of ready-made birthday messages used
+// whenever no language model is available to write one. Templates are
+// text/template files tagged with the kind of message, tone, language and
+// the ages they are meant for; a directory of files can add to or replace
+// the built-in ones.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Kinds of message a template can write
+const (
+	KindWish    = "wish"
+	KindBelated = "belated"
+)
+
+// Defaults used when a query or template leaves a tag out
+const (
+	DefaultTone     = "warm"
+	DefaultLanguage = "en"
+)
+
+// Ways of choosing between the templates that match a query
+const (
+	SelectRandom     = "random"
+	SelectRoundRobin = "round-robin"
+)
+
+//go:embed wishes/*.tmpl
+var builtin embed.FS
+
+// Data is what a template can show
+type Data struct {
+	// Name is who the message is for, or "" for a generic message
+	Name string
+	// Age is the age being turned, or 0 when it isn't known
+	Age int
+	// DaysAgo is how long ago a belated birthday was
+	DaysAgo int
+}
+
+// Query picks the templates to choose from
+type Query struct {
+	Kind     string
+	Tone     string
+	Language string
+	Age      int
+	// Exclude names templates to skip, most recently used first. When every
+	// match is excluded the least recently used one is chosen.
+	Exclude []string
+}
+
+// Template is one message in the library
+type Template struct {
+	Name     string
+	Kind     string
+	Tone     string
+	Language string
+	// Milestones are the ages the template is written for; when empty it
+	// suits any age
+	Milestones []int
+
+	tmpl *template.Template
+}
+
+// Library holds the templates and chooses between them
+type Library struct {
+	mu        sync.Mutex
+	templates map[string]Template
+	selection string
+	// builtin has only the built-in templates, to fall back on when a
+	// custom one fails; it is nil for the built-in library itself
+	builtin *Library
+	next    map[string]int
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultLibrary = mustBuiltin()
+)
+
+// fallbacks are used when no template of the query's kind is left in any
+// language, e.g. because custom files replaced every English one
+var fallbacks = map[string]Template{
+	KindWish:    mustFallback(KindWish, `🎉 Happy {{if .Age}}{{ordinal .Age}} {{end}}Birthday{{with .Name}}, {{.}}{{end}}! 🎂`),
+	KindBelated: mustFallback(KindBelated, `🎈 Belated Happy Birthday{{with .Name}}, {{.}}{{end}}! 🎂`),
+}
+
+// Default returns the library used for fallback messages
+func Default() *Library {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLibrary
+}
+
+// SetDefault replaces the library used for fallback messages
+func SetDefault(l *Library) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLibrary = l
+}
+
+// New creates a library of the built-in templates plus any *.tmpl files in
+// dir, which replace built-ins of the same name. dir may be empty.
+// selection is SelectRandom or SelectRoundRobin.
+func New(dir, selection string) (*Library, error) {
+	if selection != SelectRandom && selection != SelectRoundRobin {
+		return nil, fmt.Errorf("unknown template selection %q: use %s or %s", selection, SelectRandom, SelectRoundRobin)
+	}
+
+	templates, err := loadFS(builtin, "wishes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load built-in templates: %w", err)
+	}
+	l := &Library{templates: templates, selection: selection, next: map[string]int{}}
+	if dir == "" {
+		return l, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	custom, err := loadFS(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates from %s: %w", dir, err)
+	}
+	log.Printf("Loaded %d wish templates from %s", len(custom), dir)
+
+	merged := make(map[string]Template, len(templates)+len(custom))
+	for _, set := range []map[string]Template{templates, custom} {
+		for name, t := range set {
+			merged[name] = t
+		}
+	}
+	return &Library{templates: merged, selection: selection, next: map[string]int{}, builtin: l}, nil
+}
+
+func mustFallback(kind, text string) Template {
+	t, err := parse("fallback-"+kind, "{{/*\nkind: "+kind+"\n*/ -}}\n"+text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func mustBuiltin() *Library {
+	l, err := New("", SelectRandom)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Render writes a message for the query. When no template matches it falls
+// back to any tone in the same language, then to the default language.
+func (l *Library) Render(q Query, data Data) string {
+	text, _ := l.RenderTemplate(q, data)
+	return text
+}
+
+// RenderTemplate is Render that also returns the name of the template used
+func (l *Library) RenderTemplate(q Query, data Data) (string, string) {
+	if q.Tone == "" {
+		q.Tone = DefaultTone
+	}
+	if q.Language == "" {
+		q.Language = DefaultLanguage
+	}
+
+	t := l.choose(q)
+	text, err := t.execute(data)
+	if err != nil && l.builtin != nil {
+		// A custom template can fail on data it wasn't tried with
+		log.Printf("Wish template %s failed, using a built-in one: %v", t.Name, err)
+		return l.builtin.RenderTemplate(q, data)
+	}
+	return text, t.Name
+}
+
+// Templates returns the templates in the library, sorted by name
+func (l *Library) Templates() []Template {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]Template, 0, len(l.templates))
+	for _, t := range l.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Lookup returns the library's template with the given name
+func (l *Library) Lookup(name string) (Template, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.templates[name]
+	return t, ok
+}
+
+// choose picks one template matching the query, relaxing the tone and then
+// the language until some match. With none left of the query's kind it
+// uses the hard-coded fallback for that kind.
+func (l *Library) choose(q Query) Template {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, relaxed := range []Query{
+		q,
+		{Kind: q.Kind, Language: q.Language, Age: q.Age},
+		{Kind: q.Kind, Tone: q.Tone, Language: DefaultLanguage, Age: q.Age},
+		{Kind: q.Kind, Language: DefaultLanguage, Age: q.Age},
+	} {
+		if candidates := l.match(relaxed); len(candidates) > 0 {
+			fresh := exclude(candidates, q.Exclude)
+			if len(fresh) == 0 {
+				return leastRecent(candidates, q.Exclude)
+			}
+			return l.pick(relaxed, fresh)
+		}
+	}
+	log.Printf("No %s templates in %s or %s, using the hard-coded one", q.Kind, q.Language, DefaultLanguage)
+	if t, ok := fallbacks[q.Kind]; ok {
+		return t
+	}
+	return fallbacks[KindWish]
+}
+
+// match returns the templates for the query's kind, language and tone (any
+// tone when empty), preferring ones written for the exact age
+func (l *Library) match(q Query) []Template {
+	var milestone, general []Template
+	for _, t := range l.templates {
+		if t.Kind != q.Kind || t.Language != q.Language || (q.Tone != "" && t.Tone != q.Tone) {
+			continue
+		}
+		switch {
+		case len(t.Milestones) == 0:
+			general = append(general, t)
+		case q.Age > 0 && slices.Contains(t.Milestones, q.Age):
+			milestone = append(milestone, t)
+		}
+	}
+	if len(milestone) > 0 {
+		return milestone
+	}
+	return general
+}
+
+// exclude drops the named templates from candidates
+func exclude(candidates []Template, names []string) []Template {
+	var kept []Template
+	for _, t := range candidates {
+		if !slices.Contains(names, t.Name) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// leastRecent returns the candidate named last in exclude, which lists the
+// most recently used templates first
+func leastRecent(candidates []Template, exclude []string) Template {
+	best, bestIndex := candidates[0], -1
+	for _, t := range candidates {
+		if i := slices.Index(exclude, t.Name); i > bestIndex {
+			best, bestIndex = t, i
+		}
+	}
+	return best
+}
+
+// pick chooses one of the candidates according to the selection mode. The
+// caller must hold l.mu.
+func (l *Library) pick(q Query, candidates []Template) Template {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	if l.selection == SelectRoundRobin {
+		key := fmt.Sprintf("%s|%s|%s|%d", q.Kind, q.Tone, q.Language, q.Age)
+		i := l.next[key] % len(candidates)
+		l.next[key]++
+		return candidates[i]
+	}
+	return candidates[rand.IntN(len(candidates))]
+}
+
+func (t Template) execute(data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+var funcs = template.FuncMap{"ordinal": Ordinal}
+
+// loadFS parses every *.tmpl file in dir of fsys
+func loadFS(fsys fs.FS, dir string) (map[string]Template, error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]Template, len(paths))
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parse(path.Base(p), string(data))
+		if err != nil {
+			return nil, err
+		}
+		templates[t.Name] = t
+	}
+	return templates, nil
+}
+
+// parse reads a template file. Its tags go in a leading comment, where "#"
+// starts a note:
+//
+//	{{/*
+//	kind: wish
+//	tone: funny
+//	language: en
+//	milestones: 30, 40
+//	*/ -}}
+//	Happy birthday, {{.Name}}!
+func parse(name, text string) (Template, error) {
+	t := Template{Name: name, Kind: KindWish, Tone: DefaultTone, Language: DefaultLanguage}
+
+	if header, ok := strings.CutPrefix(strings.TrimSpace(text), "{{/*"); ok {
+		header, _, _ = strings.Cut(header, "*/")
+		for _, line := range strings.Split(header, "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.ToLower(strings.TrimSpace(value))
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "kind":
+				t.Kind = value
+			case "tone":
+				t.Tone = value
+			case "language":
+				t.Language = value
+			case "milestones":
+				for _, age := range strings.Split(value, ",") {
+					n, err := strconv.Atoi(strings.TrimSpace(age))
+					if err != nil {
+						return Template{}, fmt.Errorf("%s: invalid milestone %q", name, age)
+					}
+					t.Milestones = append(t.Milestones, n)
+				}
+			}
+		}
+	}
+	if t.Kind != KindWish && t.Kind != KindBelated {
+		return Template{}, fmt.Errorf("%s: unknown kind %q", name, t.Kind)
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return Template{}, err
+	}
+	t.tmpl = tmpl
+
+	// Catch templates that refer to fields Data doesn't have
+	if _, err := t.execute(Data{Name: "Alice", Age: 30, DaysAgo: 2}); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// Ordinal formats n as "1st", "22nd", "30th"
+func Ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,73 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplates creates a template directory holding the given files
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRenderFallsBackToTheQueryKind(t *testing.T) {
+	french := "{{/*\nkind: wish\nlanguage: fr\n*/ -}}\nJoyeux anniversaire{{with .Name}}, {{.}}{{end}} !"
+	frenchBelated := "{{/*\nkind: belated\nlanguage: fr\n*/ -}}\nJoyeux anniversaire en retard{{with .Name}}, {{.}}{{end}} !"
+	tests := []struct {
+		name  string
+		files map[string]string
+		query Query
+		want  string
+	}{
+		{
+			name: "every English wish replaced",
+			files: map[string]string{
+				"wish-warm.tmpl": french, "wish-warm-2.tmpl": french, "wish-formal.tmpl": french,
+				"wish-funny.tmpl": french, "wish-milestone.tmpl": french,
+			},
+			query: Query{Kind: KindWish, Language: "de"},
+			want:  "Happy Birthday, Alice!",
+		},
+		{
+			name:  "no English belated template",
+			files: map[string]string{"belated-warm.tmpl": frenchBelated},
+			query: Query{Kind: KindBelated},
+			want:  "Belated Happy Birthday, Alice!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := New(writeTemplates(t, tt.files), SelectRoundRobin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, name := l.RenderTemplate(tt.query, Data{Name: "Alice", DaysAgo: 2})
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("Render = %q, want it to contain %q", text, tt.want)
+			}
+			if name != "fallback-"+tt.query.Kind {
+				t.Errorf("template = %s, want the %s fallback", name, tt.query.Kind)
+			}
+		})
+	}
+}
+
+func TestRenderPrefersTheQueryLanguage(t *testing.T) {
+	l, err := New("", SelectRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, name := l.RenderTemplate(Query{Kind: KindBelated, Language: "es"}, Data{Name: "Ana", DaysAgo: 1})
+	if name != "belated-warm-es.tmpl" || !strings.Contains(text, "ayer") {
+		t.Errorf("Render = %q from %s, want the Spanish belated template", text, name)
+	}
+}
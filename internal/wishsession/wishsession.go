@@ -0,0 +1,127 @@
+// Package wishsession keeps the candidate wishes offered for one person so
+// they can be regenerated with feedback without repeating earlier drafts.
+package wishsession
+
+import (
+	"hazel_ai/internal/clients"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxAvoid caps how many earlier drafts are sent back to the model
+const maxAvoid = 20
+
+// Round is one batch of candidates
+type Round struct {
+	// Feedback is what the caller asked to change, empty for the first round
+	Feedback   string
+	Candidates []clients.Wish
+	CreatedAt  time.Time
+}
+
+// Session is the wish request and every round of candidates for it
+type Session struct {
+	ID      string
+	Tenant  string
+	Request clients.WishRequest
+	Rounds  []Round
+	// ExpiresAt is when the session is forgotten unless it is regenerated
+	ExpiresAt time.Time
+}
+
+// Previous returns the text of the most recent candidates, oldest first, for
+// WishRequest.Avoid
+func (s Session) Previous() []string {
+	var texts []string
+	for _, round := range s.Rounds {
+		for _, wish := range round.Candidates {
+			texts = append(texts, wish.Text)
+		}
+	}
+	return texts[max(0, len(texts)-maxAvoid):]
+}
+
+// Store keeps sessions in memory until they expire
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewStore creates a store whose sessions expire ttl after their last round
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		sessions: make(map[string]Session),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// SetClock replaces the store's time source
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Create starts a session with its first round of candidates
+func (s *Store) Create(tenant string, req clients.WishRequest, candidates []clients.Wish) Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+
+	session := Session{
+		ID:        uuid.New().String(),
+		Tenant:    tenant,
+		Request:   req,
+		Rounds:    []Round{{Candidates: candidates, CreatedAt: now}},
+		ExpiresAt: now.Add(s.ttl),
+	}
+	s.sessions[session.ID] = session
+	return session
+}
+
+// Get returns the tenant's session, if it exists and hasn't expired
+func (s *Store) Get(tenant, id string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(tenant, id)
+}
+
+// AddRound records another round of candidates and restarts the session's TTL
+func (s *Store) AddRound(tenant, id, feedback string, candidates []clients.Wish) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.get(tenant, id)
+	if !ok {
+		return Session{}, false
+	}
+	now := s.now()
+	session.Rounds = append(session.Rounds, Round{Feedback: feedback, Candidates: candidates, CreatedAt: now})
+	session.ExpiresAt = now.Add(s.ttl)
+	s.sessions[id] = session
+	return session, true
+}
+
+// get looks up a session. The caller must hold s.mu.
+func (s *Store) get(tenant, id string) (Session, bool) {
+	session, ok := s.sessions[id]
+	if !ok || session.Tenant != tenant {
+		return Session{}, false
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return Session{}, false
+	}
+	return session, true
+}
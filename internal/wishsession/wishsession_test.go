@@ -0,0 +1,71 @@
+package wishsession
+
+import (
+	"fmt"
+	"hazel_ai/internal/clients"
+	"testing"
+	"time"
+)
+
+func TestStoreExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	s := NewStore(time.Hour)
+	s.SetClock(func() time.Time { return now })
+
+	session := s.Create("team-a", clients.WishRequest{Name: "Ada"}, []clients.Wish{{Text: "one"}})
+	if _, ok := s.Get("team-b", session.ID); ok {
+		t.Error("another tenant can see the session")
+	}
+
+	// A new round restarts the TTL
+	now = now.Add(50 * time.Minute)
+	if _, ok := s.AddRound("team-a", session.ID, "shorter", []clients.Wish{{Text: "two"}}); !ok {
+		t.Fatal("AddRound before expiry failed")
+	}
+	now = now.Add(50 * time.Minute)
+	got, ok := s.Get("team-a", session.ID)
+	if !ok || len(got.Rounds) != 2 || got.Rounds[1].Feedback != "shorter" {
+		t.Fatalf("Get after a new round = %+v, %v; want both rounds", got, ok)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, ok := s.Get("team-a", session.ID); ok {
+		t.Error("session still there an hour after its last round")
+	}
+	if _, ok := s.AddRound("team-a", session.ID, "", []clients.Wish{{Text: "three"}}); ok {
+		t.Error("AddRound on an expired session succeeded")
+	}
+}
+
+func TestPreviousIsCapped(t *testing.T) {
+	tests := []struct {
+		rounds    int
+		wantLen   int
+		wantFirst string
+		wantLast  string
+	}{
+		{1, 3, "draft 1", "draft 3"},
+		{6, 18, "draft 1", "draft 18"},
+		{7, maxAvoid, "draft 2", "draft 21"},
+		{10, maxAvoid, "draft 11", "draft 30"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d rounds", tt.rounds), func(t *testing.T) {
+			var session Session
+			draft := 0
+			for range tt.rounds {
+				var round Round
+				for range 3 {
+					draft++
+					round.Candidates = append(round.Candidates, clients.Wish{Text: fmt.Sprintf("draft %d", draft)})
+				}
+				session.Rounds = append(session.Rounds, round)
+			}
+
+			previous := session.Previous()
+			if len(previous) != tt.wantLen || previous[0] != tt.wantFirst || previous[len(previous)-1] != tt.wantLast {
+				t.Errorf("Previous = %v, want %d drafts from %q to %q", previous, tt.wantLen, tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
@@ -1,11 +1,24 @@
 package main
 
 import (
+	"context"
+	a2alogic "hazel_ai/internal/a2a"
 	"hazel_ai/internal/agent"
+	"hazel_ai/internal/clients"
+	"hazel_ai/internal/config"
+	"hazel_ai/internal/conversation"
+	"hazel_ai/internal/dateparse"
 	"hazel_ai/internal/handlers"
+	"hazel_ai/internal/scheduler"
 	"hazel_ai/internal/store"
+	"hazel_ai/internal/task"
+	"hazel_ai/internal/templates"
+	"hazel_ai/internal/wishsession"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
@@ -29,7 +42,16 @@ func main() {
 		}
 	}
 
-	birthdayStore := store.NewBirthdayStore("birthdays.json")
+	cfg := config.Load()
+
+	birthdayStore, err := store.Open(cfg.StoreBackend, cfg.StoreFile, store.Options{
+		Backups:           cfg.StoreBackups,
+		RecoverFromBackup: cfg.StoreRecover,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open %s birthday store: %v", cfg.StoreBackend, err)
+	}
+	defer birthdayStore.Close()
 
 	err = agent.CheckForAgentCard()
 	if err != nil {
@@ -37,8 +59,80 @@ func main() {
 		log.Println("Continuing without agent card - some endpoints may not work")
 	}
 
+	leapDay, err := store.ParseLeapDayPolicy(cfg.LeapDayPolicy)
+	if err != nil {
+		log.Printf("Warning: %v, using %s", err, leapDay)
+	}
+	calendar := store.Calendar{LeapDay: leapDay}
+
+	dateOrder, err := dateparse.ParseOrder(cfg.DateOrder)
+	if err != nil {
+		log.Printf("Warning: %v, using %s", err, dateOrder)
+	}
+
+	library, err := templates.New(cfg.TemplateDir, cfg.TemplateSelection)
+	if err != nil {
+		log.Printf("Warning: %v, using the built-in wish templates", err)
+	} else {
+		templates.SetDefault(library)
+	}
+
+	geminiClient, err := clients.NewGeminiClient(cfg.Gemini)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize Gemini client: %v", err)
+		geminiClient = nil
+	}
+	wishes := wishProviders(cfg, geminiClient)
+
+	// Deliver reminders to a webhook if one is configured, otherwise just log them
+	var notifier a2alogic.Notifier = a2alogic.LogNotifier{}
+	if cfg.TelexWebhookURL != "" {
+		notifier = a2alogic.NewWebhookNotifier(cfg.TelexWebhookURL)
+	}
+	// Reminders also go to the A2A clients that registered push notification configs
+	tasks := task.NewStore(cfg.TaskTTL)
+	pusher := a2alogic.NewPushSender(cfg.PushAttempts, cfg.PushBackoff)
+	pusher.AllowPrivateHosts = cfg.PushAllowPrivate
+	pushConfigs, err := store.NewPushConfigs(cfg.PushConfigFile)
+	if err != nil {
+		log.Printf("Warning: %v, push notifications are turned off", err)
+		pushConfigs = nil
+	} else {
+		notifier = a2alogic.Notifiers{notifier, a2alogic.NewPushNotifier(pushConfigs, tasks, pusher)}
+	}
+	// Without the ledger every reminder already sent would go out again
+	ledger, err := store.NewLedger(cfg.LedgerFile)
+	if err != nil {
+		log.Fatalf("Failed to load the delivery ledger, fix or restore %s before starting: %v", cfg.LedgerFile, err)
+	}
+	wishHistory, err := store.NewWishHistory(cfg.WishHistoryFile)
+	if err != nil {
+		log.Printf("Warning: %v, wishes won't be recorded or checked for repeats", err)
+		wishHistory = nil
+	}
+	reminder := a2alogic.NewReminder(birthdayStore, wishes, notifier, ledger, wishHistory)
+	reminder.SetCalendar(calendar)
+
+	// Send belated wishes for anything missed while the server was down, and
+	// today's wishes too if the daily job already fired before the restart
+	if cfg.SchedulerEnabled && cfg.TodayCron != "" {
+		if schedule, err := scheduler.ParseCron(cfg.TodayCron); err == nil {
+			reminder.SetTodaySchedule(schedule)
+		}
+	}
+	go reminder.CatchUp(context.Background(), cfg.CatchUpDays)
+
 	router := fiber.New()
-	handlerList := handlers.NewHandler(birthdayStore)
+	handlerList := handlers.NewHandler(birthdayStore, calendar, dateparse.Parser{Order: dateOrder}, conversation.NewStore(cfg.ConversationTTL), wishes, wishsession.NewStore(cfg.WishSessionTTL), wishHistory, tasks, reminder)
+	if cfg.ToolCalling {
+		if geminiClient == nil {
+			log.Println("Warning: HAZEL_TOOL_CALLING is set but Gemini isn't configured, using the intent router")
+		}
+		handlerList.SetToolCalling(geminiClient)
+	}
+	if pushConfigs != nil {
+		handlerList.SetPushNotifications(pushConfigs, pusher)
+	}
 
 	// Telex A2A endpoint - ALL A2A communication goes through POST /
 	router.Post("/", handlerList.HandleTelexA2A)
@@ -46,6 +140,11 @@ func main() {
 	router.Get("/health", handlerList.Health)
 	router.Get("/.well-known/agent.json", handlerList.GetAgentCard)
 
+	// REST callers are scoped to the tenant their X-API-Key maps to
+	apiKeyAuth := handlers.APIKeyAuth(cfg.APIKeys)
+	router.Use("/api/birthdays", apiKeyAuth)
+	router.Use("/api/wishes", apiKeyAuth)
+
 	router.Post("/api/birthdays", handlerList.AddBirthday)
 
 	router.Get("/api/birthdays", handlerList.ListBirthdays)
@@ -54,20 +153,87 @@ func main() {
 
 	router.Get("/api/birthdays/upcoming", handlerList.GetUpcomingBirthdays)
 
+	// Single birthday endpoints - registered after /today and /upcoming so those win
+	router.Get("/api/birthdays/:id", handlerList.GetBirthday)
+	router.Put("/api/birthdays/:id", handlerList.UpdateBirthday)
+	router.Patch("/api/birthdays/:id", handlerList.PatchBirthday)
+	router.Delete("/api/birthdays/:id", handlerList.DeleteBirthday)
+	router.Get("/api/birthdays/:id/wishes", handlerList.ListBirthdayWishes)
+
 	// Birthday wish generation endpoints
 	router.Post("/api/wishes/generate", handlerList.GenerateBirthdayWish)
 	router.Get("/api/wishes/person/:id", handlerList.GenerateBirthdayWishForPerson)
 	router.Get("/api/wishes/simple", handlerList.GenerateSimpleBirthdayWish)
+	router.Post("/api/wishes/sessions", handlerList.CreateWishSession)
+	router.Get("/api/wishes/sessions/:id", handlerList.GetWishSession)
+	router.Post("/api/wishes/sessions/:id/regenerate", handlerList.RegenerateWishSession)
 
 	router.Post("/api/a2a/message", handlerList.SendA2AMessage)
 
 	router.Post("/api/telex/webhook", handlerList.UseTelexWebhook)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	// Built-in scheduler so reminders go out even if nobody calls the webhook
+	jobs := scheduler.New(nil)
+	if cfg.SchedulerEnabled {
+		addJob(jobs, "today", cfg.TodayCron, func(ctx context.Context) { reminder.Today(ctx) })
+		addJob(jobs, "tomorrow", cfg.TomorrowCron, func(ctx context.Context) { reminder.Tomorrow(ctx) })
+		addJob(jobs, "weekly_digest", cfg.DigestCron, func(ctx context.Context) { reminder.WeeklyDigest(ctx) })
+		jobs.Start()
+	}
+
+	go func() {
+		log.Printf("Starting Hazel Birthday Bot server on port %s", cfg.Port)
+		if err := router.Listen(":" + cfg.Port); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// Wait for a shutdown signal, then stop the scheduler and drain the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down...")
+	jobs.Stop()
+	if err := router.Shutdown(); err != nil {
+		log.Printf("Error shutting down server: %v", err)
 	}
+}
 
-	log.Printf("Starting Hazel Birthday Bot server on port %s", port)
-	log.Fatal(router.Listen(":" + port))
+// addJob registers a scheduled job, skipping it when its cron expression is empty
+func addJob(s *scheduler.Scheduler, name, spec string, run func(ctx context.Context)) {
+	if spec == "" {
+		log.Printf("Scheduled job %s disabled", name)
+		return
+	}
+	if err := s.Add(name, spec, run); err != nil {
+		log.Printf("Warning: Failed to schedule job: %v", err)
+	}
+}
+
+// wishProviders builds the chain of wish providers named in the config,
+// skipping any that aren't available
+func wishProviders(cfg config.Config, geminiClient *clients.GeminiClient) clients.Chain {
+	var chain clients.Chain
+	var names []string
+	for _, name := range cfg.WishProviders {
+		switch name {
+		case clients.ProviderGemini:
+			if geminiClient == nil {
+				log.Println("Warning: Gemini wish provider skipped, GEMINI_API_KEY isn't set")
+				continue
+			}
+			chain = append(chain, geminiClient)
+		case clients.ProviderOpenAI:
+			chain = append(chain, clients.NewOpenAIClient(cfg.OpenAI))
+		case clients.ProviderTemplate:
+			chain = append(chain, clients.TemplateWishes{})
+		default:
+			log.Printf("Warning: unknown wish provider %q skipped", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	log.Printf("Wish providers: %s", strings.Join(names, ", "))
+	return chain
 }